@@ -4,29 +4,241 @@
 
 package gocui
 
-import "github.com/nsf/termbox-go"
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
 
 // Attribute represents a terminal attribute, like color, font style, etc. They
 // can be combined using bitwise OR (|). Note that it is not possible to
 // combine multiple color attributes.
-type Attribute termbox.Attribute
+//
+// Besides the basic colors, an Attribute can hold a color of the 256
+// colors palette, see Color256, or a 24-bit color, see ColorRGB. Colors
+// not supported by the terminal are replaced by the closest supported
+// ones, see ColorMode.
+type Attribute uint64
 
 // Color attributes.
 const (
-	ColorDefault Attribute = Attribute(termbox.ColorDefault)
-	ColorBlack             = Attribute(termbox.ColorBlack)
-	ColorRed               = Attribute(termbox.ColorRed)
-	ColorGreen             = Attribute(termbox.ColorGreen)
-	ColorYellow            = Attribute(termbox.ColorYellow)
-	ColorBlue              = Attribute(termbox.ColorBlue)
-	ColorMagenta           = Attribute(termbox.ColorMagenta)
-	ColorCyan              = Attribute(termbox.ColorCyan)
-	ColorWhite             = Attribute(termbox.ColorWhite)
+	ColorDefault Attribute = iota
+	ColorBlack
+	ColorRed
+	ColorGreen
+	ColorYellow
+	ColorBlue
+	ColorMagenta
+	ColorCyan
+	ColorWhite
 )
 
 // Text style attributes.
 const (
-	AttrBold      Attribute = Attribute(termbox.AttrBold)
-	AttrUnderline           = Attribute(termbox.AttrUnderline)
-	AttrReverse             = Attribute(termbox.AttrReverse)
+	AttrBold Attribute = 1 << (iota + 9)
+	AttrUnderline
+	AttrReverse
+)
+
+const (
+	paletteMask = 0x1ff // palette index + 1, 0 for the default color
+	styleMask   = AttrBold | AttrUnderline | AttrReverse
+	attrRGB     = 1 << 12 // the color is stored in rgbShift..rgbShift+23
+	rgbShift    = 32
 )
+
+// Color256 returns the color of the given index of the 256 colors palette.
+// The indexes 0 to 7 are the basic colors, 8 to 15 their bright versions,
+// 16 to 231 a 6x6x6 color cube and 232 to 255 a grayscale ramp.
+func Color256(idx uint8) Attribute {
+	return Attribute(idx) + 1
+}
+
+// ColorRGB returns a 24-bit color.
+func ColorRGB(r, g, b uint8) Attribute {
+	return attrRGB | Attribute(r)<<(rgbShift+16) | Attribute(g)<<(rgbShift+8) | Attribute(b)<<rgbShift
+}
+
+// rgb returns the components of a 24-bit color.
+func (a Attribute) rgb() (r, g, b uint8, ok bool) {
+	if a&attrRGB == 0 {
+		return 0, 0, 0, false
+	}
+	c := a >> rgbShift
+	return uint8(c >> 16), uint8(c >> 8), uint8(c), true
+}
+
+// ColorMode is the set of colors a terminal can display.
+type ColorMode int
+
+// Color modes.
+const (
+	// ColorModeAuto detects the color mode of the terminal, see
+	// DetectColorMode.
+	ColorModeAuto ColorMode = iota
+
+	// ColorMode8 only supports the basic colors.
+	ColorMode8
+
+	// ColorMode256 supports the 256 colors palette.
+	ColorMode256
+
+	// ColorModeTrueColor supports 24-bit colors.
+	ColorModeTrueColor
+)
+
+// WithColorMode makes the Gui use the given color mode instead of the
+// detected one.
+func WithColorMode(mode ColorMode) Option {
+	return func(g *Gui) {
+		g.colorMode = mode
+	}
+}
+
+// DetectColorMode returns the color mode of the terminal, based on the
+// COLORTERM and TERM environment variables.
+func DetectColorMode() ColorMode {
+	if ct := os.Getenv("COLORTERM"); ct == "truecolor" || ct == "24bit" {
+		return ColorModeTrueColor
+	}
+	if strings.Contains(os.Getenv("TERM"), "256color") {
+		return ColorMode256
+	}
+	return ColorMode8
+}
+
+// degrade returns the attribute with its color replaced by the closest
+// color supported by mode.
+func (a Attribute) degrade(mode ColorMode) Attribute {
+	if mode == ColorModeTrueColor || mode == ColorModeAuto {
+		return a
+	}
+	styles := a & styleMask
+	if r, g, b, ok := a.rgb(); ok {
+		if mode == ColorMode256 {
+			return styles | Color256(nearest256(r, g, b))
+		}
+		return styles | Color256(nearest8(r, g, b))
+	}
+
+	idx := int(a&paletteMask) - 1
+	if mode == ColorMode256 || idx < 8 {
+		return a & (paletteMask | styleMask)
+	}
+	if idx < 16 {
+		return styles | Color256(uint8(idx-8))
+	}
+	r, g, b := paletteRGB(uint8(idx))
+	return styles | Color256(nearest8(r, g, b))
+}
+
+// basicRGB holds the values of the 16 first colors of the palette, as
+// displayed by xterm.
+var basicRGB = [16][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cubeLevels holds the values of the components of the 6x6x6 color cube.
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// paletteRGB returns the components of a color of the 256 colors palette.
+func paletteRGB(idx uint8) (r, g, b uint8) {
+	switch {
+	case idx < 16:
+		c := basicRGB[idx]
+		return c[0], c[1], c[2]
+	case idx < 232:
+		i := idx - 16
+		return cubeLevels[i/36], cubeLevels[i/6%6], cubeLevels[i%6]
+	default:
+		v := 8 + 10*(idx-232)
+		return v, v, v
+	}
+}
+
+// nearest256 returns the index of the color of the 256 colors palette
+// which is the closest to the given one. Only the color cube and the
+// grayscale ramp are considered, the 16 first colors depend on the
+// terminal.
+func nearest256(r, g, b uint8) uint8 {
+	level := func(v uint8) uint8 {
+		best := uint8(0)
+		for i, l := range cubeLevels {
+			if absDiff(v, l) < absDiff(v, cubeLevels[best]) {
+				best = uint8(i)
+			}
+		}
+		return best
+	}
+	cube := 16 + 36*level(r) + 6*level(g) + level(b)
+
+	i := ((int(r)+int(g)+int(b))/3 - 3) / 10
+	if i < 0 {
+		i = 0
+	} else if i > 23 {
+		i = 23
+	}
+	gray := uint8(232 + i)
+
+	if distance(r, g, b, gray) < distance(r, g, b, cube) {
+		return gray
+	}
+	return cube
+}
+
+// nearest8 returns the index of the basic color which is the closest to
+// the given one.
+func nearest8(r, g, b uint8) uint8 {
+	best := uint8(0)
+	for i := uint8(1); i < 8; i++ {
+		if distance(r, g, b, i) < distance(r, g, b, best) {
+			best = i
+		}
+	}
+	return best
+}
+
+// distance returns the squared euclidean distance between a color and a
+// color of the palette.
+func distance(r, g, b uint8, idx uint8) int {
+	pr, pg, pb := paletteRGB(idx)
+	dr, dg, db := int(r)-int(pr), int(g)-int(pg), int(b)-int(pb)
+	return dr*dr + dg*dg + db*db
+}
+
+// absDiff returns |a-b|.
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+var colorNames = []string{"default", "black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// String returns a readable representation of the attribute, like
+// "red|bold", "color(208)" or "#ff8700".
+func (a Attribute) String() string {
+	var s string
+	if r, g, b, ok := a.rgb(); ok {
+		s = fmt.Sprintf("#%02x%02x%02x", r, g, b)
+	} else if c := int(a & paletteMask); c < len(colorNames) {
+		s = colorNames[c]
+	} else {
+		s = "color(" + strconv.Itoa(c-1) + ")"
+	}
+	if a&AttrBold != 0 {
+		s += "|bold"
+	}
+	if a&AttrUnderline != 0 {
+		s += "|underline"
+	}
+	if a&AttrReverse != 0 {
+		s += "|reverse"
+	}
+	return s
+}
@@ -0,0 +1,98 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import "testing"
+
+func TestNearest256(t *testing.T) {
+	tests := []struct {
+		r, g, b uint8
+		want    uint8
+	}{
+		{0, 0, 0, 16},
+		{255, 255, 255, 231},
+		{255, 0, 0, 196},
+		{255, 135, 0, 208},
+		{95, 175, 255, 75},
+		{100, 170, 250, 75},
+		{128, 128, 128, 244},
+		{8, 8, 8, 232},
+		{240, 240, 238, 255},
+	}
+	for _, tt := range tests {
+		if got := nearest256(tt.r, tt.g, tt.b); got != tt.want {
+			t.Errorf("nearest256(%d, %d, %d) = %d, want %d", tt.r, tt.g, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNearest8(t *testing.T) {
+	tests := []struct {
+		r, g, b uint8
+		want    uint8
+	}{
+		{0, 0, 0, 0},
+		{255, 0, 0, 1},
+		{20, 200, 30, 2},
+		{200, 200, 0, 3},
+		{0, 0, 200, 4},
+		{190, 10, 210, 5},
+		{0, 190, 180, 6},
+		{250, 250, 250, 7},
+		{60, 60, 60, 0},
+	}
+	for _, tt := range tests {
+		if got := nearest8(tt.r, tt.g, tt.b); got != tt.want {
+			t.Errorf("nearest8(%d, %d, %d) = %d, want %d", tt.r, tt.g, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDegrade(t *testing.T) {
+	tests := []struct {
+		name string
+		a    Attribute
+		mode ColorMode
+		want Attribute
+	}{
+		{"rgb true color", ColorRGB(1, 2, 3), ColorModeTrueColor, ColorRGB(1, 2, 3)},
+		{"rgb auto", ColorRGB(1, 2, 3) | AttrBold, ColorModeAuto, ColorRGB(1, 2, 3) | AttrBold},
+		{"rgb to 256", ColorRGB(255, 135, 0), ColorMode256, Color256(208)},
+		{"rgb to 256 styled", ColorRGB(255, 135, 0) | AttrBold | AttrReverse, ColorMode256, Color256(208) | AttrBold | AttrReverse},
+		{"rgb to 8", ColorRGB(255, 0, 0), ColorMode8, ColorRed},
+		{"rgb to 8 styled", ColorRGB(250, 250, 250) | AttrUnderline, ColorMode8, ColorWhite | AttrUnderline},
+		{"256 kept", Color256(208), ColorMode256, Color256(208)},
+		{"cube to 8", Color256(208), ColorMode8, ColorYellow},
+		{"gray to 8", Color256(232), ColorMode8, ColorBlack},
+		{"light gray to 8", Color256(255) | AttrBold, ColorMode8, ColorWhite | AttrBold},
+		{"bright to basic", Color256(9), ColorMode8, ColorRed},
+		{"bright white to basic", Color256(15) | AttrUnderline, ColorMode8, ColorWhite | AttrUnderline},
+		{"bright kept", Color256(9), ColorMode256, Color256(9)},
+		{"basic kept", ColorGreen | AttrBold, ColorMode8, ColorGreen | AttrBold},
+		{"default kept", ColorDefault, ColorMode8, ColorDefault},
+	}
+	for _, tt := range tests {
+		if got := tt.a.degrade(tt.mode); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAttributeString(t *testing.T) {
+	tests := []struct {
+		a    Attribute
+		want string
+	}{
+		{ColorDefault, "default"},
+		{ColorRed | AttrBold, "red|bold"},
+		{Color256(208) | AttrUnderline | AttrReverse, "color(208)|underline|reverse"},
+		{ColorRGB(255, 135, 0), "#ff8700"},
+	}
+	for _, tt := range tests {
+		if got := tt.a.String(); got != tt.want {
+			t.Errorf("got %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+// Segment is a piece of text of a title or footer bar. A segment whose
+// Style is the zero value uses the style of the title or of the footer.
+type Segment struct {
+	Text  string
+	Style Style
+}
+
+// Bar is a title or footer bar, made of segments aligned on the left, at
+// the center and on the right of a side of the frame. The segments of a
+// group are drawn next to each other.
+//
+// When the bar is too small, the center is truncated first, then the left
+// and then the right, and the truncated text ends with an ellipsis.
+type Bar struct {
+	Left, Center, Right []Segment
+}
+
+// barRune is a rune of a bar with its style.
+type barRune struct {
+	ch    rune
+	style Style
+}
+
+// barRunes returns the runes of the segments. def is the style of the
+// segments without their own one.
+func barRunes(segs []Segment, def Style) []barRune {
+	var rs []barRune
+	for _, s := range segs {
+		style := s.Style
+		if style == (Style{}) {
+			style = def
+		}
+		for _, ch := range s.Text {
+			if runeWidth(ch) == 0 {
+				continue
+			}
+			rs = append(rs, barRune{ch: ch, style: style})
+		}
+	}
+	return rs
+}
+
+// barWidth returns the number of cells used by rs.
+func barWidth(rs []barRune) int {
+	w := 0
+	for _, r := range rs {
+		w += runeWidth(r.ch)
+	}
+	return w
+}
+
+// truncate returns the runes of rs fitting in n cells. If some runes are
+// dropped, the last one kept is replaced by an ellipsis.
+func truncate(rs []barRune, n int) []barRune {
+	if barWidth(rs) <= n {
+		return rs
+	}
+	if n <= 0 {
+		return nil
+	}
+	w := 0
+	for i, r := range rs {
+		rw := runeWidth(r.ch)
+		if w+rw > n-1 {
+			return append(rs[:i:i], barRune{ch: '…', style: r.style})
+		}
+		w += rw
+	}
+	return rs
+}
+
+// drawTitle draws the title bar of the view. Title is the first left
+// segment of the bar.
+func (g *Gui) drawTitle(v *View) error {
+	b := v.TitleBar
+	if v.Title != "" {
+		b.Left = append([]Segment{{Text: v.Title}}, b.Left...)
+	}
+	return g.drawBar(v, v.y0, b, RoleTitle)
+}
+
+// drawFooter draws the footer bar of the view. Footer is the last right
+// segment of the bar.
+func (g *Gui) drawFooter(v *View) error {
+	b := v.FooterBar
+	if v.Footer != "" {
+		b.Right = append(b.Right[:len(b.Right):len(b.Right)], Segment{Text: v.Footer})
+	}
+	return g.drawBar(v, v.y1, b, RoleFooter)
+}
+
+// drawBar draws a bar on the line y of the frame of the view, between its
+// corners. role is the color role of the segments without a style.
+func (g *Gui) drawBar(v *View, y int, b Bar, role string) error {
+	if y < 0 || y >= g.maxY {
+		return nil
+	}
+	start, end := v.x0+2, v.x1-2
+	width := end - start + 1
+	if width <= 0 {
+		return nil
+	}
+
+	def := g.decorationStyle(v, role)
+	left := barRunes(b.Left, def)
+	center := barRunes(b.Center, def)
+	right := barRunes(b.Right, def)
+
+	// groups are separated by at least one cell
+	avail := width
+	rx := end - barWidth(right) + 1
+	if rx < start {
+		// a truncated right group starts like the left one, even if a
+		// wide rune leaves a cell free at its end
+		rx = start
+	}
+	right = truncate(right, avail)
+	if len(right) > 0 {
+		avail -= barWidth(right) + 1
+	}
+	left = truncate(left, avail)
+	if len(left) > 0 {
+		avail -= barWidth(left) + 1
+	}
+	center = truncate(center, avail)
+
+	lw, cw, rw := barWidth(left), barWidth(center), barWidth(right)
+	cx := start + (width-cw)/2
+	if lo := start + lw + 1; lw > 0 && cx < lo {
+		cx = lo
+	}
+	if hi := end - rw - cw; rw > 0 && cx > hi {
+		cx = hi
+	}
+
+	if err := g.drawBarRunes(start, y, left); err != nil {
+		return err
+	}
+	if err := g.drawBarRunes(cx, y, center); err != nil {
+		return err
+	}
+	return g.drawBarRunes(rx, y, right)
+}
+
+// drawBarRunes draws rs from the point (x, y).
+func (g *Gui) drawBarRunes(x, y int, rs []barRune) error {
+	for _, r := range rs {
+		if x >= g.maxX {
+			break
+		}
+		if x >= 0 {
+			if err := g.setRuneStyle(x, y, r.ch, r.style); err != nil {
+				return err
+			}
+		}
+		x += runeWidth(r.ch)
+	}
+	return nil
+}
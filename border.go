@@ -0,0 +1,268 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+// BorderStyle defines the runes used to draw a side of the frame of a view.
+type BorderStyle int
+
+// Border styles.
+const (
+	BorderSingle  BorderStyle = iota // ─ │
+	BorderDouble                     // ═ ║
+	BorderRounded                    // ─ │ with rounded corners
+	BorderThick                      // ━ ┃
+	BorderASCII                      // - | +
+	BorderNone                       // the side is not drawn
+)
+
+// Border defines the style of each side of the frame of a view. The zero
+// value draws single lines on every side.
+type Border struct {
+	Top, Right, Bottom, Left BorderStyle
+}
+
+// UniformBorder returns a Border using the same style on every side.
+func UniformBorder(s BorderStyle) Border {
+	return Border{Top: s, Right: s, Bottom: s, Left: s}
+}
+
+// horizontal returns the rune of a horizontal side of style s.
+func (s BorderStyle) horizontal() rune {
+	switch s {
+	case BorderDouble:
+		return '═'
+	case BorderThick:
+		return '━'
+	case BorderASCII:
+		return '-'
+	}
+	return '─'
+}
+
+// vertical returns the rune of a vertical side of style s.
+func (s BorderStyle) vertical() rune {
+	switch s {
+	case BorderDouble:
+		return '║'
+	case BorderThick:
+		return '┃'
+	case BorderASCII:
+		return '|'
+	}
+	return '│'
+}
+
+// onFrame returns true if the point (x, y) is on a drawn side of the
+// frame of the view.
+func (v *View) onFrame(x, y int) bool {
+	if !v.Frame || v.Hidden {
+		return false
+	}
+	b := v.Border
+	inX, inY := x >= v.x0 && x <= v.x1, y >= v.y0 && y <= v.y1
+	switch {
+	case y == v.y0 && inX && b.Top != BorderNone,
+		y == v.y1 && inX && b.Bottom != BorderNone,
+		x == v.x0 && inY && b.Left != BorderNone,
+		x == v.x1 && inY && b.Right != BorderNone:
+		return true
+	}
+	return false
+}
+
+// lineWeight is the kind of line of an arm of a box drawing rune.
+type lineWeight int
+
+const (
+	lineNone lineWeight = iota
+	lineLight
+	lineHeavy
+	lineDouble
+	lineASCII
+)
+
+// boxArms holds the lines going from the center of a box drawing rune to
+// each side of its cell.
+type boxArms struct {
+	up, down, left, right lineWeight
+}
+
+// replace returns the arms with the lines of weight from replaced by to.
+func (a boxArms) replace(from, to lineWeight) boxArms {
+	for _, w := range []*lineWeight{&a.up, &a.down, &a.left, &a.right} {
+		if *w == from {
+			*w = to
+		}
+	}
+	return a
+}
+
+// lighten returns the arms with the lines of weight w made light, on the
+// horizontal and/or the vertical axis.
+func (a boxArms) lighten(w lineWeight, horizontal, vertical bool) boxArms {
+	l := a.replace(w, lineLight)
+	if horizontal {
+		a.left, a.right = l.left, l.right
+	}
+	if vertical {
+		a.up, a.down = l.up, l.down
+	}
+	return a
+}
+
+// boxRunes holds the arms of the box drawing runes.
+var boxRunes = map[rune]boxArms{
+	'─': {left: lineLight, right: lineLight},
+	'━': {left: lineHeavy, right: lineHeavy},
+	'│': {up: lineLight, down: lineLight},
+	'┃': {up: lineHeavy, down: lineHeavy},
+	'┌': {down: lineLight, right: lineLight},
+	'┍': {down: lineLight, right: lineHeavy},
+	'┎': {down: lineHeavy, right: lineLight},
+	'┏': {down: lineHeavy, right: lineHeavy},
+	'┐': {down: lineLight, left: lineLight},
+	'┑': {down: lineLight, left: lineHeavy},
+	'┒': {down: lineHeavy, left: lineLight},
+	'┓': {down: lineHeavy, left: lineHeavy},
+	'└': {up: lineLight, right: lineLight},
+	'┕': {up: lineLight, right: lineHeavy},
+	'┖': {up: lineHeavy, right: lineLight},
+	'┗': {up: lineHeavy, right: lineHeavy},
+	'┘': {up: lineLight, left: lineLight},
+	'┙': {up: lineLight, left: lineHeavy},
+	'┚': {up: lineHeavy, left: lineLight},
+	'┛': {up: lineHeavy, left: lineHeavy},
+	'├': {up: lineLight, down: lineLight, right: lineLight},
+	'┝': {up: lineLight, down: lineLight, right: lineHeavy},
+	'┞': {up: lineHeavy, down: lineLight, right: lineLight},
+	'┟': {up: lineLight, down: lineHeavy, right: lineLight},
+	'┠': {up: lineHeavy, down: lineHeavy, right: lineLight},
+	'┡': {up: lineHeavy, down: lineLight, right: lineHeavy},
+	'┢': {up: lineLight, down: lineHeavy, right: lineHeavy},
+	'┣': {up: lineHeavy, down: lineHeavy, right: lineHeavy},
+	'┤': {up: lineLight, down: lineLight, left: lineLight},
+	'┥': {up: lineLight, down: lineLight, left: lineHeavy},
+	'┦': {up: lineHeavy, down: lineLight, left: lineLight},
+	'┧': {up: lineLight, down: lineHeavy, left: lineLight},
+	'┨': {up: lineHeavy, down: lineHeavy, left: lineLight},
+	'┩': {up: lineHeavy, down: lineLight, left: lineHeavy},
+	'┪': {up: lineLight, down: lineHeavy, left: lineHeavy},
+	'┫': {up: lineHeavy, down: lineHeavy, left: lineHeavy},
+	'┬': {down: lineLight, left: lineLight, right: lineLight},
+	'┭': {down: lineLight, left: lineHeavy, right: lineLight},
+	'┮': {down: lineLight, left: lineLight, right: lineHeavy},
+	'┯': {down: lineLight, left: lineHeavy, right: lineHeavy},
+	'┰': {down: lineHeavy, left: lineLight, right: lineLight},
+	'┱': {down: lineHeavy, left: lineHeavy, right: lineLight},
+	'┲': {down: lineHeavy, left: lineLight, right: lineHeavy},
+	'┳': {down: lineHeavy, left: lineHeavy, right: lineHeavy},
+	'┴': {up: lineLight, left: lineLight, right: lineLight},
+	'┵': {up: lineLight, left: lineHeavy, right: lineLight},
+	'┶': {up: lineLight, left: lineLight, right: lineHeavy},
+	'┷': {up: lineLight, left: lineHeavy, right: lineHeavy},
+	'┸': {up: lineHeavy, left: lineLight, right: lineLight},
+	'┹': {up: lineHeavy, left: lineHeavy, right: lineLight},
+	'┺': {up: lineHeavy, left: lineLight, right: lineHeavy},
+	'┻': {up: lineHeavy, left: lineHeavy, right: lineHeavy},
+	'┼': {up: lineLight, down: lineLight, left: lineLight, right: lineLight},
+	'┽': {up: lineLight, down: lineLight, left: lineHeavy, right: lineLight},
+	'┾': {up: lineLight, down: lineLight, left: lineLight, right: lineHeavy},
+	'┿': {up: lineLight, down: lineLight, left: lineHeavy, right: lineHeavy},
+	'╀': {up: lineHeavy, down: lineLight, left: lineLight, right: lineLight},
+	'╁': {up: lineLight, down: lineHeavy, left: lineLight, right: lineLight},
+	'╂': {up: lineHeavy, down: lineHeavy, left: lineLight, right: lineLight},
+	'╃': {up: lineHeavy, down: lineLight, left: lineHeavy, right: lineLight},
+	'╄': {up: lineHeavy, down: lineLight, left: lineLight, right: lineHeavy},
+	'╅': {up: lineLight, down: lineHeavy, left: lineHeavy, right: lineLight},
+	'╆': {up: lineLight, down: lineHeavy, left: lineLight, right: lineHeavy},
+	'╇': {up: lineHeavy, down: lineLight, left: lineHeavy, right: lineHeavy},
+	'╈': {up: lineLight, down: lineHeavy, left: lineHeavy, right: lineHeavy},
+	'╉': {up: lineHeavy, down: lineHeavy, left: lineHeavy, right: lineLight},
+	'╊': {up: lineHeavy, down: lineHeavy, left: lineLight, right: lineHeavy},
+	'╋': {up: lineHeavy, down: lineHeavy, left: lineHeavy, right: lineHeavy},
+	'═': {left: lineDouble, right: lineDouble},
+	'║': {up: lineDouble, down: lineDouble},
+	'╒': {down: lineLight, right: lineDouble},
+	'╓': {down: lineDouble, right: lineLight},
+	'╔': {down: lineDouble, right: lineDouble},
+	'╕': {down: lineLight, left: lineDouble},
+	'╖': {down: lineDouble, left: lineLight},
+	'╗': {down: lineDouble, left: lineDouble},
+	'╘': {up: lineLight, right: lineDouble},
+	'╙': {up: lineDouble, right: lineLight},
+	'╚': {up: lineDouble, right: lineDouble},
+	'╛': {up: lineLight, left: lineDouble},
+	'╜': {up: lineDouble, left: lineLight},
+	'╝': {up: lineDouble, left: lineDouble},
+	'╞': {up: lineLight, down: lineLight, right: lineDouble},
+	'╟': {up: lineDouble, down: lineDouble, right: lineLight},
+	'╠': {up: lineDouble, down: lineDouble, right: lineDouble},
+	'╡': {up: lineLight, down: lineLight, left: lineDouble},
+	'╢': {up: lineDouble, down: lineDouble, left: lineLight},
+	'╣': {up: lineDouble, down: lineDouble, left: lineDouble},
+	'╤': {down: lineLight, left: lineDouble, right: lineDouble},
+	'╥': {down: lineDouble, left: lineLight, right: lineLight},
+	'╦': {down: lineDouble, left: lineDouble, right: lineDouble},
+	'╧': {up: lineLight, left: lineDouble, right: lineDouble},
+	'╨': {up: lineDouble, left: lineLight, right: lineLight},
+	'╩': {up: lineDouble, left: lineDouble, right: lineDouble},
+	'╪': {up: lineLight, down: lineLight, left: lineDouble, right: lineDouble},
+	'╫': {up: lineDouble, down: lineDouble, left: lineLight, right: lineLight},
+	'╬': {up: lineDouble, down: lineDouble, left: lineDouble, right: lineDouble},
+	'╴': {left: lineLight},
+	'╵': {up: lineLight},
+	'╶': {right: lineLight},
+	'╷': {down: lineLight},
+	'╸': {left: lineHeavy},
+	'╹': {up: lineHeavy},
+	'╺': {right: lineHeavy},
+	'╻': {down: lineHeavy},
+	'╼': {left: lineLight, right: lineHeavy},
+	'╽': {up: lineLight, down: lineHeavy},
+	'╾': {left: lineHeavy, right: lineLight},
+	'╿': {up: lineHeavy, down: lineLight},
+	'-': {left: lineASCII, right: lineASCII},
+	'|': {up: lineASCII, down: lineASCII},
+	'+': {up: lineASCII, down: lineASCII, left: lineASCII, right: lineASCII},
+}
+
+// roundedCorners associates the light corners with their rounded version.
+var roundedCorners = map[rune]rune{'┌': '╭', '┐': '╮', '└': '╰', '┘': '╯'}
+
+// junctions is the reverse of boxRunes.
+var junctions = make(map[boxArms]rune)
+
+func init() {
+	for ch, a := range boxRunes {
+		junctions[a] = ch
+	}
+}
+
+// junctionRune returns the rune joining the given arms. There are no
+// runes mixing heavy and double lines, nor for every mix of light and
+// double lines, so the lines are made light, one axis then both, until a
+// rune is found. ASCII lines only join with each other.
+func junctionRune(a boxArms) (rune, bool) {
+	if a == (boxArms{}) {
+		return ' ', false
+	}
+	if a.replace(lineASCII, lineNone) == (boxArms{}) {
+		return '+', true
+	}
+	a = a.replace(lineASCII, lineLight)
+
+	tries := []boxArms{a}
+	for _, w := range []lineWeight{lineHeavy, lineDouble} {
+		tries = append(tries, a.lighten(w, true, false), a.lighten(w, false, true), a.lighten(w, true, true))
+	}
+	tries = append(tries, a.lighten(lineHeavy, true, true).lighten(lineDouble, true, true))
+
+	for _, try := range tries {
+		if ch, ok := junctions[try]; ok {
+			return ch, true
+		}
+	}
+	return ' ', false
+}
@@ -0,0 +1,51 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import "testing"
+
+func TestJunctionRune(t *testing.T) {
+	tests := []struct {
+		name string
+		a    boxArms
+		ch   rune
+		ok   bool
+	}{
+		{"light cross", boxArms{lineLight, lineLight, lineLight, lineLight}, '┼', true},
+		{"heavy tee", boxArms{lineHeavy, lineHeavy, lineNone, lineHeavy}, '┣', true},
+		{"double corner", boxArms{lineNone, lineDouble, lineNone, lineDouble}, '╔', true},
+		{"light and heavy", boxArms{lineLight, lineHeavy, lineLight, lineHeavy}, '╆', true},
+		{"light and double", boxArms{lineDouble, lineDouble, lineLight, lineNone}, '╢', true},
+		// there are no runes mixing heavy and double lines: the heavy
+		// lines are made light
+		{"heavy and double corner", boxArms{lineNone, lineHeavy, lineNone, lineDouble}, '╒', true},
+		{"heavy and double tee", boxArms{lineHeavy, lineHeavy, lineDouble, lineNone}, '╡', true},
+		// there is no rune with double lines on three different axes
+		{"double and light cross", boxArms{lineDouble, lineLight, lineDouble, lineLight}, '┼', true},
+		{"ascii", boxArms{lineASCII, lineASCII, lineASCII, lineNone}, '+', true},
+		{"ascii and light", boxArms{lineASCII, lineASCII, lineLight, lineNone}, '┤', true},
+		{"ascii and double", boxArms{lineASCII, lineNone, lineDouble, lineNone}, '╛', true},
+		{"no line", boxArms{}, ' ', false},
+	}
+	for _, tt := range tests {
+		ch, ok := junctionRune(tt.a)
+		if ch != tt.ch || ok != tt.ok {
+			t.Errorf("%s: junctionRune(%+v) = %q, %v, want %q, %v", tt.name, tt.a, ch, ok, tt.ch, tt.ok)
+		}
+	}
+}
+
+func TestJunctionRuneArms(t *testing.T) {
+	// every box drawing rune joins its own arms, the ASCII lines only
+	// join with '+'
+	for ch, a := range boxRunes {
+		if ch == '-' || ch == '|' {
+			continue
+		}
+		if got, ok := junctionRune(a); !ok || got != ch {
+			t.Errorf("junctionRune(arms of %q) = %q, %v", ch, got, ok)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import "strings"
+
+// Clipboard holds the text copied or cut from the views of a Gui. The
+// views of a Gui share its clipboard, see Gui.Clipboard. If it has a
+// provider, the text is also copied to the system clipboard.
+type Clipboard struct {
+	text     string
+	linewise bool
+	provider ClipboardProvider
+	screen   Screen // screen of the Gui
+}
+
+// SetProvider sets the provider giving access to the system clipboard,
+// nil to only use the internal clipboard.
+func (c *Clipboard) SetProvider(p ClipboardProvider) {
+	c.provider = p
+	c.bindProvider()
+}
+
+// bindProvider makes an OSC52Provider without writer send its sequences
+// through the screen of the Gui.
+func (c *Clipboard) bindProvider() {
+	if p, ok := c.provider.(*OSC52Provider); ok && p.w == nil && c.screen != nil {
+		p.w = screenWriter{c.screen}
+	}
+}
+
+// SetText replaces the content of the clipboard. If linewise is true, the
+// text is made of whole lines, each one ending with a line break, which
+// are pasted under the line of the cursor. The error of the provider is
+// returned, the internal clipboard is set anyway.
+func (c *Clipboard) SetText(text string, linewise bool) error {
+	c.text, c.linewise = text, linewise
+	if c.provider == nil {
+		return nil
+	}
+	return c.provider.Copy(text)
+}
+
+// Text returns the content of the clipboard and whether it is made of
+// whole lines. The content of the system clipboard is returned if it has
+// been changed by another program, the internal clipboard is used if the
+// provider fails.
+func (c *Clipboard) Text() (text string, linewise bool) {
+	if c.provider != nil {
+		if s, err := c.provider.Paste(); err == nil && s != c.text {
+			return s, false
+		}
+	}
+	return c.text, c.linewise
+}
+
+// Clipboard returns the clipboard shared by the views of the GUI.
+func (g *Gui) Clipboard() *Clipboard {
+	return g.clipboard
+}
+
+// viewClipboard returns the clipboard of the view, which is the clipboard
+// of its Gui, or its own one if it has not been created by a Gui.
+func (v *View) viewClipboard() *Clipboard {
+	if v.clipboard == nil {
+		v.clipboard = &Clipboard{}
+	}
+	return v.clipboard
+}
+
+// Copy copies the selected text to the clipboard and clears the
+// selection. If nothing is selected, the line of the cursor is copied. The
+// error of the system clipboard is returned, see Clipboard.SetText.
+func (v *View) Copy() error {
+	if len(v.lines) == 0 {
+		return nil
+	}
+	if v.selMode == SelectNone {
+		v.StartSelection(SelectLine)
+	}
+	err := v.viewClipboard().SetText(v.SelectedText(), v.selMode == SelectLine)
+	v.ClearSelection()
+	return err
+}
+
+// Cut copies the selected text to the clipboard, then deletes it. If
+// nothing is selected, the line of the cursor is cut. The text is deleted
+// even if the system clipboard fails.
+func (v *View) Cut() error {
+	if len(v.lines) == 0 {
+		return nil
+	}
+	if v.selMode == SelectNone {
+		v.StartSelection(SelectLine)
+	}
+	err := v.viewClipboard().SetText(v.SelectedText(), v.selMode == SelectLine)
+	v.DeleteSelection()
+	return err
+}
+
+// Paste inserts the content of the clipboard at the cursor position, or
+// replaces the selected text with it. Whole lines are inserted under the
+// line of the cursor. A paste is a single command of View.Actions,
+// whatever the number of lines.
+func (v *View) Paste() {
+	text, linewise := v.viewClipboard().Text()
+	if text == "" {
+		return
+	}
+	if v.selMode != SelectNone {
+		v.ReplaceSelection(strings.TrimSuffix(text, "\n"))
+		return
+	}
+
+	rx, ry, err := v.realPosition(v.cx, v.cy)
+	if err != nil {
+		return
+	}
+	// the cursor can be after the end of the buffer
+	if len(v.lines) == 0 {
+		rx, ry = 0, 0
+	} else if ry >= len(v.lines) {
+		ry = len(v.lines) - 1
+		rx = len(v.lines[ry])
+		if !linewise {
+			text = "\n" + text
+		}
+	} else if rx > len(v.lines[ry]) {
+		rx = len(v.lines[ry])
+	}
+	if !linewise {
+		t := stringText(text)
+		ax, ay := textEnd(rx, ry, t)
+		v.replaceRange(rx, ry, rx, ry, t, ax, ay)
+		return
+	}
+
+	// whole lines are inserted under the line of the cursor
+	text = strings.TrimSuffix(text, "\n")
+	if ry+1 < len(v.lines) {
+		v.replaceRange(0, ry+1, 0, ry+1, stringText(text+"\n"), 0, ry+1)
+	} else if len(v.lines) > 0 {
+		x := len(v.lines[ry])
+		v.replaceRange(x, ry, x, ry, stringText("\n"+text), 0, ry+1)
+	} else {
+		v.replaceRange(0, 0, 0, 0, stringText(text), 0, 0)
+	}
+}
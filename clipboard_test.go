@@ -0,0 +1,205 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+const clipText = "hello world\nsecond line\nthird"
+
+// clipHarness returns a harness with an editable view holding clipText,
+// using p as system clipboard.
+func clipHarness(t *testing.T, p ClipboardProvider) (*Harness, *View) {
+	var v *View
+	h, err := NewHarness(20, 8, testLayout(viewSpec{"a", 0, 0, 19, 7, func(nv *View) {
+		v = nv
+		v.Editable = true
+		fmt.Fprint(v, clipText)
+	}}), WithClipboardProvider(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h, v
+}
+
+// selectText selects the text from (x0, y0) to (x1, y1) with the given
+// mode.
+func selectText(v *View, mode SelectionMode, x0, y0, x1, y1 int) {
+	v.AbsMoveCursor(x0, y0, false)
+	v.StartSelection(mode)
+	v.AbsMoveCursor(x1, y1, false)
+}
+
+func TestClipboard(t *testing.T) {
+	tests := []struct {
+		name     string
+		steps    []func(v *View) // the view is drawn after each step
+		clip     string          // content of the system clipboard
+		buffer   string
+		undos    int // number of undos restoring clipText
+		linewise bool
+	}{
+		{
+			name: "copy and paste characters",
+			steps: []func(v *View){func(v *View) {
+				selectText(v, SelectChar, 6, 0, 3, 1)
+				v.Copy()
+			}, func(v *View) {
+				v.AbsMoveCursor(2, 2, false)
+				v.Paste()
+			}},
+			clip:   "world\nsec",
+			buffer: "hello world\nsecond line\nthworld\nsecird\n",
+			undos:  1,
+		},
+		{
+			name: "copy and paste lines",
+			steps: []func(v *View){func(v *View) {
+				v.Copy()
+			}, func(v *View) {
+				v.AbsMoveCursor(4, 1, false)
+				v.Paste()
+			}},
+			clip:     "hello world\n",
+			buffer:   "hello world\nsecond line\nhello world\nthird\n",
+			undos:    1,
+			linewise: true,
+		},
+		{
+			name: "paste lines after the last line",
+			steps: []func(v *View){func(v *View) {
+				selectText(v, SelectLine, 0, 0, 0, 1)
+				v.Copy()
+			}, func(v *View) {
+				v.AbsMoveCursor(0, 2, false)
+				v.Paste()
+			}},
+			clip:     "hello world\nsecond line\n",
+			buffer:   "hello world\nsecond line\nthird\nhello world\nsecond line\n",
+			undos:    1,
+			linewise: true,
+		},
+		{
+			name: "cut and paste characters",
+			steps: []func(v *View){func(v *View) {
+				selectText(v, SelectChar, 0, 1, 7, 1)
+				v.Cut()
+			}, func(v *View) {
+				v.AbsMoveCursor(0, 2, false)
+				v.Paste()
+			}},
+			clip:   "second ",
+			buffer: "hello world\nline\nsecond third\n",
+			undos:  2,
+		},
+		{
+			name: "cut and paste lines",
+			steps: []func(v *View){func(v *View) {
+				v.AbsMoveCursor(3, 1, false)
+				v.Cut()
+			}, func(v *View) {
+				v.Paste()
+			}},
+			clip:     "second line\n",
+			buffer:   "hello world\nthird\nsecond line\n",
+			undos:    2,
+			linewise: true,
+		},
+		{
+			name: "paste over a selection",
+			steps: []func(v *View){func(v *View) {
+				selectText(v, SelectChar, 0, 0, 5, 0)
+				v.Copy()
+			}, func(v *View) {
+				selectText(v, SelectChar, 0, 2, 5, 2)
+				v.Paste()
+			}},
+			clip:   "hello",
+			buffer: "hello world\nsecond line\nhello\n",
+			undos:  1,
+		},
+	}
+	for _, tt := range tests {
+		p := &FakeClipboardProvider{}
+		h, v := clipHarness(t, p)
+		for _, step := range tt.steps {
+			step(v)
+			if err := h.Draw(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if p.Text != tt.clip {
+			t.Errorf("%s: system clipboard %q, want %q", tt.name, p.Text, tt.clip)
+		}
+		if text, linewise := h.Gui.Clipboard().Text(); text != tt.clip || linewise != tt.linewise {
+			t.Errorf("%s: clipboard %q, %v, want %q, %v", tt.name, text, linewise, tt.clip, tt.linewise)
+		}
+		if got := v.Buffer(); got != tt.buffer {
+			t.Errorf("%s: buffer %q, want %q", tt.name, got, tt.buffer)
+		}
+		for i := 0; i < tt.undos; i++ {
+			v.Actions.Undo()
+		}
+		if got := v.Buffer(); got != clipText+"\n" {
+			t.Errorf("%s: buffer %q after %d undos", tt.name, got, tt.undos)
+		}
+		h.Close()
+	}
+}
+
+func TestClipboardSystemText(t *testing.T) {
+	p := &FakeClipboardProvider{}
+	h, v := clipHarness(t, p)
+	defer h.Close()
+
+	// text copied by another program is pasted as characters
+	v.Copy()
+	p.Text = "ext\nern"
+	v.AbsMoveCursor(0, 2, false)
+	v.Paste()
+	if got, want := v.Buffer(), "hello world\nsecond line\next\nernthird\n"; got != want {
+		t.Errorf("buffer %q, want %q", got, want)
+	}
+	v.Actions.Undo()
+	if got := v.Buffer(); got != clipText+"\n" {
+		t.Errorf("buffer %q after undo", got)
+	}
+}
+
+func TestClipboardProviderError(t *testing.T) {
+	errClip := errors.New("no clipboard")
+	p := &FakeClipboardProvider{Text: "old", Err: errClip}
+	h, v := clipHarness(t, p)
+	defer h.Close()
+
+	selectText(v, SelectChar, 0, 0, 5, 0)
+	if err := v.Copy(); err != errClip {
+		t.Errorf("Copy() = %v, want %v", err, errClip)
+	}
+	if err := h.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	v.AbsMoveCursor(0, 1, false)
+	if err := v.Cut(); err != errClip {
+		t.Errorf("Cut() = %v, want %v", err, errClip)
+	}
+	if err := h.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := v.Buffer(), "hello world\nthird\n"; got != want {
+		t.Errorf("buffer %q after Cut, want %q", got, want)
+	}
+	// the internal clipboard is used
+	v.Paste()
+	if got, want := v.Buffer(), "hello world\nthird\nsecond line\n"; got != want {
+		t.Errorf("buffer %q after Paste, want %q", got, want)
+	}
+	if p.Text != "old" {
+		t.Errorf("system clipboard changed to %q", p.Text)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ErrClipboardUnsupported is returned by a ClipboardProvider which cannot
+// perform an operation, like reading the clipboard with OSC 52.
+var ErrClipboardUnsupported = errors.New("clipboard operation not supported")
+
+// ClipboardProvider gives access to the clipboard of the system, so that
+// the text copied in the views can be pasted in other programs and the
+// other way around. See WithClipboardProvider.
+type ClipboardProvider interface {
+	// Copy replaces the content of the system clipboard with text.
+	Copy(text string) error
+
+	// Paste returns the content of the system clipboard.
+	Paste() (string, error)
+}
+
+// WithClipboardProvider makes the clipboard of the Gui copy to and paste
+// from the system clipboard through p. The internal clipboard is used
+// when p fails.
+func WithClipboardProvider(p ClipboardProvider) Option {
+	return func(g *Gui) {
+		g.clipboard.provider = p
+	}
+}
+
+// CommandProvider is a ClipboardProvider running external programs, like
+// xclip or wl-copy. The text is written to the standard input of CopyCmd
+// and read from the standard output of PasteCmd.
+type CommandProvider struct {
+	CopyCmd, PasteCmd []string
+}
+
+// Copy runs CopyCmd with text as input.
+func (p *CommandProvider) Copy(text string) error {
+	if len(p.CopyCmd) == 0 {
+		return ErrClipboardUnsupported
+	}
+	cmd := exec.Command(p.CopyCmd[0], p.CopyCmd[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
+
+// Paste runs PasteCmd and returns its output.
+func (p *CommandProvider) Paste() (string, error) {
+	if len(p.PasteCmd) == 0 {
+		return "", ErrClipboardUnsupported
+	}
+	out, err := exec.Command(p.PasteCmd[0], p.PasteCmd[1:]...).Output()
+	return string(out), err
+}
+
+// available reports whether the programs of p are installed.
+func (p *CommandProvider) available() bool {
+	for _, cmd := range [][]string{p.CopyCmd, p.PasteCmd} {
+		if len(cmd) == 0 {
+			return false
+		}
+		if _, err := exec.LookPath(cmd[0]); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// clipboardCommands holds the known clipboard programs, with the
+// environment variable which must be set for them to work.
+var clipboardCommands = []struct {
+	env      string
+	provider CommandProvider
+}{
+	{"WAYLAND_DISPLAY", CommandProvider{[]string{"wl-copy"}, []string{"wl-paste", "--no-newline"}}},
+	{"DISPLAY", CommandProvider{[]string{"xclip", "-selection", "clipboard", "-in"}, []string{"xclip", "-selection", "clipboard", "-out"}}},
+	{"DISPLAY", CommandProvider{[]string{"xsel", "--clipboard", "--input"}, []string{"xsel", "--clipboard", "--output"}}},
+	{"", CommandProvider{[]string{"pbcopy"}, []string{"pbpaste"}}},
+}
+
+// DetectClipboardProvider returns a provider using the first clipboard
+// program available among wl-copy, xclip, xsel and pbcopy. If there is
+// none, for instance over SSH, it returns an OSC52Provider writing to the
+// Screen of the Gui.
+func DetectClipboardProvider() ClipboardProvider {
+	for _, c := range clipboardCommands {
+		if c.env != "" && os.Getenv(c.env) == "" {
+			continue
+		}
+		if p := c.provider; p.available() {
+			return &p
+		}
+	}
+	return NewOSC52Provider(nil)
+}
+
+// OSC52Provider is a ClipboardProvider copying with the OSC 52 escape
+// sequence, which asks the terminal to set the system clipboard. It works
+// over SSH, but the clipboard cannot be read: Paste always fails.
+type OSC52Provider struct {
+	w io.Writer
+
+	// If Tmux is true, the sequence is wrapped to pass through tmux.
+	Tmux bool
+}
+
+// NewOSC52Provider returns an OSC52Provider writing the sequences to w.
+// If w is nil, they are sent through the Screen of the Gui the provider
+// is given to, see WithClipboardProvider and Clipboard.SetProvider. The
+// sequences are wrapped for tmux if the TMUX environment variable is set.
+func NewOSC52Provider(w io.Writer) *OSC52Provider {
+	return &OSC52Provider{w: w, Tmux: os.Getenv("TMUX") != ""}
+}
+
+// Copy writes the OSC 52 sequence setting the clipboard to text. It
+// returns ErrClipboardUnsupported if the provider has no writer.
+func (p *OSC52Provider) Copy(text string) error {
+	if p.w == nil {
+		return ErrClipboardUnsupported
+	}
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if p.Tmux {
+		seq = "\x1bPtmux;" + strings.Replace(seq, "\x1b", "\x1b\x1b", -1) + "\x1b\\"
+	}
+	_, err := fmt.Fprint(p.w, seq)
+	return err
+}
+
+// Paste returns ErrClipboardUnsupported.
+func (p *OSC52Provider) Paste() (string, error) {
+	return "", ErrClipboardUnsupported
+}
+
+// screenWriter is an io.Writer sending escape sequences through a Screen.
+type screenWriter struct {
+	s Screen
+}
+
+func (w screenWriter) Write(b []byte) (int, error) {
+	w.s.WriteSequence(string(b))
+	return len(b), nil
+}
+
+// FakeClipboardProvider is a ClipboardProvider keeping the text in memory.
+// It stands for the system clipboard in tests.
+type FakeClipboardProvider struct {
+	// Text is the content of the fake system clipboard.
+	Text string
+
+	// Err, if not nil, is returned by Copy and Paste.
+	Err error
+}
+
+// Copy sets Text, unless Err is set.
+func (p *FakeClipboardProvider) Copy(text string) error {
+	if p.Err != nil {
+		return p.Err
+	}
+	p.Text = text
+	return nil
+}
+
+// Paste returns Text, or Err if it is set.
+func (p *FakeClipboardProvider) Paste() (string, error) {
+	if p.Err != nil {
+		return "", p.Err
+	}
+	return p.Text, nil
+}
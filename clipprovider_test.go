@@ -0,0 +1,99 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestOSC52Provider(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		tmux bool
+		want string
+	}{
+		{"empty", "", false, "\x1b]52;c;\a"},
+		{"text", "hi", false, "\x1b]52;c;aGk=\a"},
+		{"lines", "a\nb", false, "\x1b]52;c;YQpi\a"},
+		{"tmux", "hi", true, "\x1bPtmux;\x1b\x1b]52;c;aGk=\a\x1b\\"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		p := &OSC52Provider{w: &buf, Tmux: tt.tmux}
+		if err := p.Copy(tt.text); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("%s: wrote %q, want %q", tt.name, got, tt.want)
+		}
+		if _, err := p.Paste(); err != ErrClipboardUnsupported {
+			t.Errorf("%s: Paste() error %v, want %v", tt.name, err, ErrClipboardUnsupported)
+		}
+	}
+}
+
+func TestNewOSC52Provider(t *testing.T) {
+	defer os.Setenv("TMUX", os.Getenv("TMUX"))
+	for _, tmux := range []string{"", "/tmp/tmux-1000/default,1,0"} {
+		os.Setenv("TMUX", tmux)
+		if p := NewOSC52Provider(nil); p.Tmux != (tmux != "") {
+			t.Errorf("TMUX=%q: Tmux is %v", tmux, p.Tmux)
+		}
+	}
+}
+
+func TestOSC52Clipboard(t *testing.T) {
+	var buf bytes.Buffer
+	h, v := clipHarness(t, &OSC52Provider{w: &buf})
+	defer h.Close()
+
+	v.Copy()
+	if got, want := buf.String(), "\x1b]52;c;aGVsbG8gd29ybGQK\a"; got != want {
+		t.Errorf("wrote %q, want %q", got, want)
+	}
+	// the clipboard cannot be read, the internal one is used
+	v.Paste()
+	if got, want := v.Buffer(), "hello world\nhello world\nsecond line\nthird\n"; got != want {
+		t.Errorf("buffer %q, want %q", got, want)
+	}
+}
+
+func TestOSC52Screen(t *testing.T) {
+	p := NewOSC52Provider(nil)
+	p.Tmux = false
+	h, v := clipHarness(t, p)
+	defer h.Close()
+
+	v.Copy()
+	// the sequence is written after the cells of the next flush
+	if got := h.Screen.Sequences(); len(got) != 0 {
+		t.Errorf("sequences %q before the flush", got)
+	}
+	if err := h.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"\x1b]52;c;aGVsbG8gd29ybGQK\a"}
+	if got := h.Screen.Sequences(); !reflect.DeepEqual(got, want) {
+		t.Errorf("sequences %q, want %q", got, want)
+	}
+
+	if err := NewOSC52Provider(nil).Copy("text"); err != ErrClipboardUnsupported {
+		t.Errorf("Copy() without screen: error %v, want %v", err, ErrClipboardUnsupported)
+	}
+}
+
+func TestCommandProviderUnsupported(t *testing.T) {
+	p := &CommandProvider{}
+	if err := p.Copy("text"); err != ErrClipboardUnsupported {
+		t.Errorf("Copy() error %v, want %v", err, ErrClipboardUnsupported)
+	}
+	if _, err := p.Paste(); err != ErrClipboardUnsupported {
+		t.Errorf("Paste() error %v, want %v", err, ErrClipboardUnsupported)
+	}
+}
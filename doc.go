@@ -51,6 +51,97 @@ Views can also be created using relative coordinates:
 		// ...
 	}
 
+Instead of absolute coordinates, viewNodes can place their children
+automatically. The coordinates are computed again on each iteration of the
+main loop, so the layout follows the size of the terminal:
+
+	g.SetViewNodeLayout("", gocui.LayoutVertical)
+	g.SetLayoutView("main", "", gocui.Flex(1))
+	g.SetLayoutView("cmdline", "", gocui.Fixed(2))
+
+The frame of a view can use a different border style on each side, and the
+frame of the current view can be highlighted:
+
+	v.Border = gocui.UniformBorder(gocui.BorderRounded)
+	g.FocusFgColor = gocui.ColorGreen
+
+Titles and footers can be made of several segments, aligned on the left, at
+the center or on the right of the frame:
+
+	v.TitleBar.Center = []gocui.Segment{{Text: "INSERT"}}
+	v.TitleBar.Right = []gocui.Segment{{Text: "12:4", Style: gocui.Style{Fg: gocui.ColorYellow}}}
+
+Long lines can be wrapped between words, with their continuation lines
+indented and marked in a column on the left of the view:
+
+	v.Wrap = true
+	v.WordWrap = true
+	v.WrapIndent = true
+	v.WrapMarker = '↪'
+
+Line numbers can be displayed on the left of the view, relative to the line
+of the cursor if needed:
+
+	v.LineNumbers = gocui.LineNumbersHybrid
+
+Signs can be attached to the lines, they follow their line when the buffer
+is edited:
+
+	v.SignColumn = true
+	v.SetSign(12, gocui.Sign{Group: "breakpoint", Rune: '●', Tooltip: "break here"})
+
+Text can be selected from the position of the cursor, by characters, by
+lines or by blocks. The selection follows the cursor and can be replaced
+as a single undoable command:
+
+	v.StartSelection(gocui.SelectChar)
+	// move the cursor
+	text := v.SelectedText()
+	v.ReplaceSelection(strings.ToUpper(text))
+
+The views of a GUI share a clipboard. Copy, Cut and Paste work on the
+selection, or on the line of the cursor if nothing is selected:
+
+	v.Cut()
+	// move the cursor
+	v.Paste()
+
+The clipboard can be synchronized with the clipboard of the system, using
+xclip, wl-copy or pbcopy when they are installed and the OSC 52 escape
+sequence otherwise, which also works over SSH:
+
+	err := g.Init(gocui.WithClipboardProvider(gocui.DetectClipboardProvider()))
+
+Any edit of the buffer can be undone when it is made with ReplaceRange,
+and several edits can be undone together in a transaction:
+
+	err := v.Actions.Transaction("rename", func() error {
+		return v.ReplaceRange(4, 10, 7, 10, "name")
+	})
+
+The edits of a view are kept in an undo tree: an edit made after an undo
+starts a new branch, and the other branches can still be reached:
+
+	v.Actions.Undo()
+	v.Actions.SwitchBranch(1) // Redo follows the next branch
+	v.Actions.EarlierBy(30 * time.Second)
+	v.Actions.GoTo(12)
+
+The undo history can be saved next to the edited file and restored when
+the file is opened again, unless it has been modified in the meantime:
+
+	err := v.SaveHistory(path)
+	// later, once the file is loaded in the view
+	if err := v.LoadHistory(path); err == gocui.ErrHistoryMismatch {
+		// the file has changed, the history is lost
+	}
+
+The memory used by the undo history can be bounded, the oldest commands
+being removed or grouped together when the limits are exceeded:
+
+	v.Actions.SetLimits(gocui.UndoLimits{MaxCommands: 1000, MaxBytes: 1 << 20, Compact: true})
+	usage := v.Actions.Usage()
+
 Configure keybindings:
 
 	if err := g.SetKeybinding("viewname", gocui.KeyEnter, gocui.ModNone, fcn); err != nil {
@@ -67,6 +158,9 @@ Mouse events are handled like any other keybinding:
 		// handle error
 	}
 
+When the mouse is enabled, the edge between two children of a horizontal or
+vertical layout can be dragged to resize them.
+
 IMPORTANT: Views can only be created, destroyed or updated in three ways: from
 layout funcions, from keybinding callbacks or via *Gui.Execute(). The reason
 for this is that it allows gocui to be conccurent-safe. So, if you want to
@@ -105,6 +199,21 @@ DefaultEditor can be taken as example to create your own custom Editor:
 		}
 	}
 
+GUIs can be run without a terminal, for instance in tests, using a
+SimulationScreen. A Harness injects events and returns the rendered screen:
+
+	h, err := gocui.NewHarness(80, 24, layout)
+	if err != nil {
+		// handle error
+	}
+	h.Screen.InjectKey(gocui.KeyEnter, 0, gocui.ModNone)
+	if err := h.Run(); err != nil {
+		// handle error
+	}
+	rendered := h.Screen.String()
+
+Package gocuitest compares such rendered screens against golden files.
+
 For more information, see the examples in folder "_examples/".
 */
 package gocui
@@ -0,0 +1,129 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+// ratioScale is the unit of the sizes set by dragging an edge: a child of
+// ratio r covers r/ratioScale of its container.
+const ratioScale = 10000
+
+// minDragSize is the smallest size a child can be given by dragging an
+// edge, which keeps one visible cell inside its frame.
+const minDragSize = 2
+
+// edgeDrag represents an edge between two siblings of a linear layout,
+// being dragged with the mouse.
+type edgeDrag struct {
+	c             *Container
+	before, after string // names of the children sharing the edge
+}
+
+// onDrag handles the mouse events used to drag the edges of linear
+// layouts. Pressing the left button on an edge shared by two children
+// starts the drag, the edge follows the mouse until the button is
+// released. It returns true if the event has been consumed.
+func (g *Gui) onDrag(ev *Event) bool {
+	if g.drag == nil {
+		if ev.Key != MouseLeft {
+			return false
+		}
+		g.drag = findEdge(g.viewTree, ev.MouseX, ev.MouseY)
+		return g.drag != nil
+	}
+
+	switch ev.Key {
+	case MouseLeft:
+		g.drag.move(ev.MouseX, ev.MouseY)
+	case MouseRelease:
+		g.drag.move(ev.MouseX, ev.MouseY)
+		g.drag = nil
+	default:
+		g.drag = nil
+		return false
+	}
+	g.arrange()
+	return true
+}
+
+// findEdge returns the edge shared by two children of a linear layout at
+// the given position, or nil if there is none. Nested layouts are looked
+// up first.
+func findEdge(c *Container, x, y int) *edgeDrag {
+	for _, node := range c.childrens {
+		if cont, ok := node.(*Container); ok {
+			if d := findEdge(cont, x, y); d != nil {
+				return d
+			}
+		}
+	}
+	if c.layout != LayoutHorizontal && c.layout != LayoutVertical {
+		return nil
+	}
+
+	nodes, items := c.linearItems()
+	for i := 0; i < len(nodes)-1; i++ {
+		x0, y0, x1, y1 := nodes[i].Position()
+		if c.layout == LayoutHorizontal && x == x1 && y >= y0 && y <= y1 ||
+			c.layout == LayoutVertical && y == y1 && x >= x0 && x <= x1 {
+			return &edgeDrag{c: c, before: items[i].name, after: items[i+1].name}
+		}
+	}
+	return nil
+}
+
+// move places the dragged edge at the given position, as far as the
+// children sharing it can be resized. The new sizes are stored as ratios
+// of the container, so they are kept when it is resized.
+func (d *edgeDrag) move(x, y int) {
+	var before, after geom
+	for _, node := range d.c.childrens {
+		switch node.Name() {
+		case d.before:
+			before = node
+		case d.after:
+			after = node
+		}
+	}
+	if before == nil || after == nil {
+		return
+	}
+
+	bx0, by0, _, _ := before.Position()
+	_, _, ax1, ay1 := after.Position()
+	first, last, pos := bx0, ax1, x
+	if d.c.layout == LayoutVertical {
+		first, last, pos = by0, ay1, y
+	}
+
+	bi, ai := d.c.item(d.before), d.c.item(d.after)
+	low, high := first+minDragSize, last-minDragSize
+	if bi.Min > 0 && first+bi.Min > low {
+		low = first + bi.Min
+	}
+	if bi.Max > 0 && first+bi.Max < high {
+		high = first + bi.Max
+	}
+	if ai.Max > 0 && last-ai.Max > low {
+		low = last - ai.Max
+	}
+	if ai.Min > 0 && last-ai.Min < high {
+		high = last - ai.Min
+	}
+	if low > high {
+		return
+	}
+	if pos < low {
+		pos = low
+	} else if pos > high {
+		pos = high
+	}
+
+	start, end := d.c.mainAxis()
+	total := end - start
+	if total <= 0 {
+		return
+	}
+	bi.ratio = ((pos-first)*ratioScale + total/2) / total
+	ai.ratio = ((last-pos)*ratioScale + total/2) / total
+}
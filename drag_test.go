@@ -0,0 +1,108 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import "testing"
+
+// edgeLayout is a layout of two views side by side, sharing an edge.
+func edgeLayout(g *Gui) error {
+	if err := g.SetViewNodeLayout("", LayoutHorizontal); err != nil {
+		return err
+	}
+	if _, err := g.SetLayoutView("left", "", Flex(1)); err != nil && err != ErrUnknownView {
+		return err
+	}
+	if _, err := g.SetLayoutView("right", "", Fixed(10)); err != nil && err != ErrUnknownView {
+		return err
+	}
+	return nil
+}
+
+// edgeOf returns the position of the edge between the views of
+// edgeLayout.
+func edgeOf(t *testing.T, g *Gui) int {
+	_, _, x1, _, err := g.ViewPosition("left")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x0, _, _, _, err := g.ViewPosition("right")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x0 != x1 {
+		t.Fatalf("views do not share an edge: %d, %d", x1, x0)
+	}
+	return x1
+}
+
+func TestDragEdge(t *testing.T) {
+	h, err := NewHarness(41, 6, edgeLayout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	if x := edgeOf(t, h.Gui); x != 30 {
+		t.Fatalf("initial edge at %d, want 30", x)
+	}
+
+	h.Screen.InjectMouse(30, 2, MouseLeft, ModNone)
+	h.Screen.InjectMouse(25, 3, MouseLeft, ModNone)
+	if err := h.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if x := edgeOf(t, h.Gui); x != 25 {
+		t.Errorf("edge at %d while dragging, want 25", x)
+	}
+	h.Screen.InjectMouse(10, 3, MouseRelease, ModNone)
+	if err := h.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if x := edgeOf(t, h.Gui); x != 10 {
+		t.Errorf("edge at %d after release, want 10", x)
+	}
+
+	// the drag has ended, moving the mouse does not resize the views
+	h.Screen.InjectMouse(20, 3, MouseLeft, ModNone)
+	if err := h.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if x := edgeOf(t, h.Gui); x != 10 {
+		t.Errorf("edge at %d after a click, want 10", x)
+	}
+
+	// the sizes are kept as ratios of the container
+	h.Screen.InjectResize(81, 6)
+	if err := h.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if x := edgeOf(t, h.Gui); x != 20 {
+		t.Errorf("edge at %d after resize, want 20", x)
+	}
+}
+
+func TestDragEdgeBounds(t *testing.T) {
+	h, err := NewHarness(41, 6, edgeLayout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	h.Screen.InjectMouse(30, 2, MouseLeft, ModNone)
+	h.Screen.InjectMouse(45, 2, MouseRelease, ModNone)
+	if err := h.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if x := edgeOf(t, h.Gui); x != 40-minDragSize {
+		t.Errorf("edge at %d, want %d", x, 40-minDragSize)
+	}
+	h.Screen.InjectMouse(40-minDragSize, 2, MouseLeft, ModNone)
+	h.Screen.InjectMouse(-5, 2, MouseRelease, ModNone)
+	if err := h.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if x := edgeOf(t, h.Gui); x != minDragSize {
+		t.Errorf("edge at %d, want %d", x, minDragSize)
+	}
+}
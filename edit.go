@@ -4,7 +4,7 @@
 
 package gocui
 
-const maxInt = int(^uint(0) >> 1)
+import "errors"
 
 // Editor interface must be satisfied by gocui editors.
 type Editor interface {
@@ -31,6 +31,8 @@ func simpleEditor(v *View, key Key, ch rune, mod Modifier) {
 		v.EditWrite(ch)
 	case key == KeySpace:
 		v.EditWrite(' ')
+	case key == KeyTab:
+		v.EditTab()
 	case key == KeyBackspace || key == KeyBackspace2:
 		v.EditDelete(true)
 	case key == KeyDelete:
@@ -43,22 +45,50 @@ func simpleEditor(v *View, key Key, ch rune, mod Modifier) {
 // EditWrite writes a rune at the cursor position.
 func (v *View) EditWrite(ch rune) {
 	rx, ry, _ := v.realPosition(v.cx, v.cy)
-	if ch != ' ' {
-		v.Actions.Exec(NewWriteCmd(v, rx, ry, ch))
-	} else {
-		v.Actions.Exec(NewSpaceCmd(v, rx, ry))
+	x0, y0, text := v.insertionPoint(rx, ry)
+	last := len(text) - 1
+	text[last] = append(text[last], cell{chr: ch})
+	x1, y1 := x0, y0
+	if v.Overwrite && ry < len(v.lines) && rx < len(v.lines[ry]) {
+		x1 = rx + 1
+	}
+	ax, ay := textEnd(x0, y0, text)
+	v.edit(x0, y0, x1, y1, text, ax, ay)
+	if v.Wrap {
+		v.placeCursor(rx+1, ry)
+	} else if runeWidth(ch) > 0 {
+		// combining characters are displayed with the previous character
+		v.MoveCursor(1, 0, true)
+	}
+}
+
+// EditTab inserts a tab at the cursor position, or spaces up to the next
+// tab stop if ExpandTab is true.
+func (v *View) EditTab() {
+	if !v.ExpandTab {
+		v.EditWrite('\t')
+		return
+	}
+	tw := v.tabWidth()
+	for n := tw - v.textColumn()%tw; n > 0; n-- {
+		v.EditWrite(' ')
 	}
-	v.writeRune(v.cx, v.cy, ch)
-	v.MoveCursor(1, 0, true)
 }
 
 // EditNewLine inserts a new line under the cursor.
 func (v *View) EditNewLine() {
 
 	rx, ry, _ := v.realPosition(v.cx, v.cy)
-	v.Actions.Exec(NewNewLineCmd(v, rx, ry))
-
-	v.breakLine(v.cx, v.cy)
+	if ry < len(v.lines) {
+		if rx > len(v.lines[ry]) {
+			rx = len(v.lines[ry])
+		}
+		v.edit(rx, ry, rx, ry, [][]cell{nil, nil}, 0, ry+1)
+	}
+	if v.Wrap {
+		v.placeCursor(0, ry+1)
+		return
+	}
 
 	y := v.oy + v.cy
 	if y >= len(v.viewLines) || (y >= 0 && y < len(v.viewLines) &&
@@ -75,17 +105,23 @@ func (v *View) EditNewLine() {
 // with the upper or the lower one, given the boolean value
 func (v *View) EditPermutLines(up bool) {
 	rx, ry, _ := v.realPosition(v.Cursor())
+	y, dy := ry+1, 1
 	if up {
-		if err := v.permutLines(ry-1, ry); err == nil {
-			v.MoveCursor(0, -1, true)
-			v.Actions.Exec(NewUpPermutCmd(v, rx, ry, -1))
-		}
-	} else {
-		if err := v.permutLines(ry+1, ry); err == nil {
-			v.MoveCursor(0, 1, true)
-			v.Actions.Exec(NewDownPermutCmd(v, rx, ry, +1))
-		}
+		y, dy = ry-1, -1
+	}
+	if y < 0 || y >= len(v.lines) || ry >= len(v.lines) {
+		return
 	}
+	y0, y1 := y, ry
+	if y0 > y1 {
+		y0, y1 = y1, y0
+	}
+	text := [][]cell{
+		append([]cell(nil), v.lines[y1]...),
+		append([]cell(nil), v.lines[y0]...),
+	}
+	v.edit(0, y0, len(v.lines[y1]), y1, text, rx, y)
+	v.MoveCursor(0, dy, true)
 }
 
 // EditDelete deletes a rune at the cursor position. back determines the
@@ -101,53 +137,142 @@ func (v *View) EditDelete(back bool) {
 	}
 
 	rx, ry, _ := v.realPosition(v.cx, v.cy)
+	if v.Wrap {
+		v.wrappedDelete(rx, ry, back)
+		return
+	}
 
-	maxX, _ := v.Size()
+	tw := v.tabWidth()
 	if back {
 		if x == 0 { // start of the line
 			if y < 1 {
 				return
 			}
 
-			var maxPrevWidth int
-			if v.Wrap {
-				maxPrevWidth = maxX
-			} else {
-				maxPrevWidth = maxInt
-			}
-
-			if v.viewLines[y].linesX == 0 { // regular line
-				px, py := 0, 0
-				if ry > 0 {
-					py = ry - 1
-					px = len(v.lines[py])
-				}
-				if err := v.mergeLines(v.cy - 1); err == nil {
-					v.Actions.Exec(NewBackDelLineCmd(v, px, py))
-				}
-				if len(v.viewLines[y-1].line) < maxPrevWidth {
-					v.MoveCursor(-1, 0, true)
-				}
-			} else { // wrapped line
-				v.Actions.Exec(NewBackDeleteCmd(v, rx, ry, v.lines[ry][rx-1]))
-				v.deleteRune(len(v.viewLines[y-1].line)-1, v.cy-1)
-				v.MoveCursor(-1, 0, true)
+			if ry > 0 && ry < len(v.lines) {
+				px := len(v.lines[ry-1])
+				v.edit(px, ry-1, 0, ry, [][]cell{nil}, px, ry-1)
 			}
+			v.MoveCursor(-1, 0, true)
 		} else { // middle/end of the line
-			v.Actions.Exec(NewBackDeleteCmd(v, rx, ry, v.lines[ry][rx-1]))
-			v.deleteRune(v.cx-1, v.cy)
+			v.deleteCharacter(rx, ry, true)
 			v.MoveCursor(-1, 0, true)
 		}
 	} else {
-		if x == len(v.viewLines[y].line) { // end of the line
-			if err := v.mergeLines(v.cy); err == nil {
-				v.Actions.Exec(NewFwdDelLineCmd(v, rx, ry))
+		if x == v.viewLines[y].width(tw) { // end of the line
+			if ry+1 < len(v.lines) {
+				n := len(v.lines[ry])
+				v.edit(n, ry, 0, ry+1, [][]cell{nil}, n, ry)
 			}
 		} else { // start/middle of the line
-			v.Actions.Exec(NewFwdDeleteCmd(v, rx, ry, v.lines[ry][rx]))
-			v.deleteRune(v.cx, v.cy)
+			v.deleteCharacter(rx, ry, false)
+		}
+	}
+}
+
+// wrappedDelete deletes the character before the position (x, y) of the
+// internal buffer if back is true, the one at this position otherwise, in
+// a view whose lines are wrapped. The line is merged with the previous or
+// the next one at its edges, and the cursor follows the position.
+func (v *View) wrappedDelete(x, y int, back bool) {
+	switch {
+	case back && x == 0:
+		if y < 1 {
+			return
+		}
+		px, py := len(v.lines[y-1]), y-1
+		if y < len(v.lines) {
+			v.edit(px, py, 0, y, [][]cell{nil}, px, py)
+		}
+		v.placeCursor(px, py)
+	case back:
+		start := clusterStart(v.lines[y], x)
+		v.deleteCharacter(x, y, true)
+		v.placeCursor(start, y)
+	case x >= len(v.lines[y]):
+		if n := len(v.lines[y]); y+1 < len(v.lines) {
+			v.edit(n, y, 0, y+1, [][]cell{nil}, n, y)
+		}
+		v.placeCursor(x, y)
+	default:
+		v.deleteCharacter(x, y, false)
+		v.placeCursor(x, y)
+	}
+}
+
+// deleteCharacter deletes the character before the position (x, y) of the
+// internal buffer if back is true, the one at this position otherwise.
+// The combining characters of the character are deleted with it.
+func (v *View) deleteCharacter(x, y int, back bool) {
+	line := v.lines[y]
+	if x > len(line) {
+		return
+	}
+	if back {
+		start := clusterStart(line, x)
+		v.edit(start, y, x, y, [][]cell{nil}, start, y)
+		return
+	}
+	v.edit(x, y, clusterEnd(line, x), y, [][]cell{nil}, x, y)
+}
+
+// insertionPoint returns the position of the internal buffer where a text
+// is inserted when the cursor is at the position (x, y), which can be
+// after the end of its line or of the buffer, and the text filling the gap
+// with line breaks and null characters, to which the text must be added.
+func (v *View) insertionPoint(x, y int) (x0, y0 int, pad [][]cell) {
+	if len(v.lines) == 0 {
+		pad = make([][]cell, y+1)
+		pad[y] = make([]cell, x)
+		return 0, 0, pad
+	}
+	if last := len(v.lines) - 1; y > last {
+		pad = make([][]cell, y-last+1)
+		pad[y-last] = make([]cell, x)
+		return len(v.lines[last]), last, pad
+	}
+	if n := len(v.lines[y]); x > n {
+		return n, y, [][]cell{make([]cell, x-n)}
+	}
+	return x, y, [][]cell{nil}
+}
+
+// edit replaces the text of the internal buffer from (x0, y0) to (x1, y1)
+// with text, adding the command to View.Actions, where it can be merged
+// with the previous one. (ax, ay) is the position of the cursor after the
+// edit, but the cursor is not moved.
+func (v *View) edit(x0, y0, x1, y1 int, text [][]cell, ax, ay int) {
+	x, y, _ := v.realPosition(v.cx, v.cy)
+	old := v.textRange(x0, y0, x1, y1)
+	v.Actions.Exec(NewReplaceRangeCmd(v, x, y, x0, y0, old, text, ax, ay))
+	v.replaceText(x0, y0, x1, y1, text)
+}
+
+// replaceRange is like edit, but the command is not merged with the other
+// ones and the cursor is moved to (ax, ay).
+func (v *View) replaceRange(x0, y0, x1, y1 int, text [][]cell, ax, ay int) {
+	v.Actions.Cut()
+	v.edit(x0, y0, x1, y1, text, ax, ay)
+	v.Actions.Cut()
+	v.AbsMoveCursor(ax, ay, false)
+}
+
+// ReplaceRange replaces the text of the internal buffer from (x0, y0) to
+// (x1, y1), excluded, with s, as a single command of View.Actions, and
+// moves the cursor to the end of s. Any edit can be made undoable this
+// way, for instance a replacement of all the occurrences of a word in a
+// transaction, see Context.Transaction.
+func (v *View) ReplaceRange(x0, y0, x1, y1 int, s string) error {
+	if len(v.lines) > 0 || x0 != 0 || y0 != 0 || x1 != 0 || y1 != 0 {
+		if y0 < 0 || y1 >= len(v.lines) || y1 < y0 || y1 == y0 && x1 < x0 ||
+			x0 < 0 || x0 > len(v.lines[y0]) || x1 < 0 || x1 > len(v.lines[y1]) {
+			return errors.New("invalid range")
 		}
 	}
+	text := stringText(s)
+	ax, ay := textEnd(x0, y0, text)
+	v.replaceRange(x0, y0, x1, y1, text, ax, ay)
+	return nil
 }
 
 // isEmpty checks if the view has no line yet
@@ -155,9 +280,13 @@ func (v *View) isEmpty() bool {
 	return v.lines == nil
 }
 
-// bol : beginning of line
+// bol : beginning of line, after the indentation of a continuation line
 func (v *View) bol() bool {
-	return v.ox == 0 && v.cx == 0
+	indent := 0
+	if vy := v.oy + v.cy; vy >= 0 && vy < len(v.viewLines) {
+		indent = v.viewLines[vy].indent
+	}
+	return v.ox == 0 && v.cx <= indent
 }
 
 // bob : beginning of buffer
@@ -177,12 +306,13 @@ func (v *View) eol() bool {
 // eob : end of line in the buffer
 // warning : lines in a buffer does not end with '\0' or '\n'
 func (v *View) eob() bool {
-	return v.cx+v.ox == len(v.viewLines[v.oy].line)
+	return v.cx+v.ox == v.viewLines[v.oy].width(v.tabWidth())
 }
 
 // eov : end of view
 func (v *View) eov() bool {
-	return v.cx+1 == v.x1-v.x0-1
+	maxX, _ := v.textSize()
+	return v.cx+1 == maxX
 }
 
 // firstLine checks if the current cursor is placed in the first line of the file
@@ -233,8 +363,10 @@ func (v *View) adjustPositionToCurrentString() {
 	}
 	if vy < len(v.viewLines) {
 		vline := v.viewLines[vy]
-		if vx > len(vline.line) {
-			v.goToEndOfLine(len(vline.line))
+		if w := vline.width(v.tabWidth()); vx > w {
+			v.goToEndOfLine(w)
+		} else {
+			v.snapCursor()
 		}
 	}
 }
@@ -242,7 +374,7 @@ func (v *View) adjustPositionToCurrentString() {
 // goToEndOfLine will place the cursor at the end of the line given the length
 // of the line to feet
 func (v *View) goToEndOfLine(lineLength int) {
-	maxX, _ := v.Size()
+	maxX, _ := v.textSize()
 	if lineLength-v.ox < maxX {
 		if lineLength < v.ox {
 			v.ox = lineLength
@@ -254,22 +386,15 @@ func (v *View) goToEndOfLine(lineLength int) {
 	}
 }
 
-// getPreviousLineLength will return the length of the previous line. The current
-// cursor's position need to be contain into the view area.
+// getPreviousLineLength will return the length of the previous line of the
+// view, including its indentation. The previous line can be above the view.
 // Take into account wrap and side effect for the first line of the view
 func (v *View) getPreviousLineLength() (prevLineWidth int) {
-	maxX, _ := v.Size()
-	if v.firstBufferLine() {
-		_, ry, err := v.realPosition(v.cx, v.cy)
-		if err != nil {
-			return
-		}
-		line := v.lines[ry]
-		prevLineWidth = len(line) % maxX
-	} else {
-		prevLineWidth = len(v.viewLines[v.oy+v.cy-1].line)
+	vy := v.oy + v.cy - 1
+	if vy < 0 || vy >= len(v.viewLines) {
+		return
 	}
-	return
+	return v.viewLines[vy].width(v.tabWidth())
 }
 
 // moveOneRuneForward will move the cursor one character forward and adjust the
@@ -278,25 +403,31 @@ func (v *View) moveOneRuneForward(writeMode bool) {
 	if v.isEmpty() || (v.lastLine() && v.eol()) {
 		return
 	}
+	maxX, _ := v.textSize()
+	w := v.cursorRuneWidth()
+	wrapped := v.Wrap && v.endOfWrappedLine()
 	if v.eol() && writeMode {
-		v.cx++
-	} else if v.eol() && !writeMode || v.eov() && v.Wrap {
+		v.cx += w
+	} else if v.eol() && !writeMode || wrapped {
 		_, oy := v.Origin()
 		if v.lastBufferLine() {
 			v.SetOrigin(0, oy+1)
 		} else {
 			v.SetOrigin(0, oy)
 		}
-		if v.eov() && v.Wrap {
-			v.cx = 1
+		if wrapped {
+			// the cursor passes the first character of the next line
+			next := v.viewLines[v.oy+v.cy+1]
+			v.cx = next.column(clusterEnd(next.line, 0), v.tabWidth())
 		} else {
 			v.cx = 0
 		}
 		v.cy++
-	} else if v.eov() && !v.Wrap {
-		v.ox++
+	} else if !v.Wrap && v.cx+w >= maxX {
+		v.ox += v.cx + w - maxX + 1
+		v.cx = maxX - 1
 	} else {
-		v.cx++
+		v.cx += w
 	}
 }
 
@@ -307,6 +438,12 @@ func (v *View) moveOneRuneBackward() {
 		return
 	}
 	if v.bol() {
+		// the end of a line continued on the next one is the position of
+		// the start of the next one, so the cursor passes its last character
+		continued := false
+		if vy := v.oy + v.cy; vy < len(v.viewLines) {
+			continued = v.viewLines[vy].linesX > 0
+		}
 		if v.firstBufferLine() {
 			v.oy--
 		}
@@ -314,21 +451,82 @@ func (v *View) moveOneRuneBackward() {
 			v.cy--
 		}
 
+		tw := v.tabWidth()
 		vline := v.viewLines[v.oy+v.cy]
-		len := len(vline.line)
-		if v.Wrap {
-			v.cx = len
-		} else {
-			v.goToEndOfLine(len)
+		switch {
+		case v.Wrap && continued && len(vline.line) > 0:
+			v.cx = vline.column(clusterStart(vline.line, len(vline.line)), tw)
+		case v.Wrap:
+			v.cx = vline.width(tw)
+		default:
+			v.goToEndOfLine(vline.width(tw))
 		}
 	} else if v.bob() {
-		v.ox--
+		if v.ox -= v.prevRuneWidth(); v.ox < 0 {
+			v.ox = 0
+		}
 	} else {
-		v.cx--
+		if v.cx -= v.prevRuneWidth(); v.cx < 0 {
+			v.ox += v.cx
+			v.cx = 0
+		}
 	}
 	return
 }
 
+// endOfWrappedLine checks if the cursor is at the end of a line of the
+// view continued on the next line of the view.
+func (v *View) endOfWrappedLine() bool {
+	vy := v.oy + v.cy
+	if vy < 0 || vy+1 >= len(v.viewLines) {
+		return false
+	}
+	vline := v.viewLines[vy]
+	return v.viewLines[vy+1].linesY == vline.linesY && v.ox+v.cx >= vline.width(v.tabWidth())
+}
+
+// cursorRuneWidth returns the number of columns of the character of the
+// internal buffer under the cursor, 1 if there is none.
+func (v *View) cursorRuneWidth() int {
+	rx, ry, err := v.realPosition(v.cx, v.cy)
+	if err != nil || ry >= len(v.lines) || rx >= len(v.lines[ry]) {
+		return 1
+	}
+	if w := charWidth(v.lines[ry][rx].chr, v.textColumn(), v.tabWidth()); w > 0 {
+		return w
+	}
+	return 1
+}
+
+// textColumn returns the column of the cursor relative to the start of the
+// text of its line of the view, after the indentation.
+func (v *View) textColumn() int {
+	col := v.ox + v.cx
+	if vy := v.oy + v.cy; vy >= 0 && vy < len(v.viewLines) {
+		col -= v.viewLines[vy].indent
+	}
+	return col
+}
+
+// prevRuneWidth returns the number of columns of the character displayed
+// before the cursor, 1 if there is none.
+func (v *View) prevRuneWidth() int {
+	vy := v.oy + v.cy
+	if vy < 0 || vy >= len(v.viewLines) {
+		return 1
+	}
+	vline := v.viewLines[vy]
+	col := v.ox + v.cx
+	i := vline.index(col, v.tabWidth())
+	if i == 0 || i > len(vline.line) {
+		return 1
+	}
+	if w := col - vline.column(clusterStart(vline.line, i), v.tabWidth()); w > 0 {
+		return w
+	}
+	return 1
+}
+
 // moveOneRuneForward will move the cursor one line upper and adjust the
 // origin of the view if necessary
 func (v *View) moveOneLineUpper() {
@@ -390,8 +588,37 @@ func (v *View) MoveCursor(dx, dy int, writeMode bool) {
 
 // Moves the cursor from the beginning taking into account
 // the width of the line/view, displacing the origin if necessary.
+// x and y are coordinates of the internal buffer.
 func (v *View) AbsMoveCursor(x, y int, overWrite bool) {
+	if v.Wrap {
+		v.SetOrigin(0, 0)
+		v.placeCursor(x, y)
+		return
+	}
+	if v.tainted {
+		v.updateViewLines()
+	}
 	v.SetOrigin(0, 0)
 	v.SetCursor(0, 0)
-	v.MoveCursor(x, y, overWrite)
+	v.MoveCursor(v.cursorSteps(x, y), y, overWrite)
+}
+
+// cursorSteps returns the number of cursor moves from the start of the line
+// y of the internal buffer to the rune x. A combining character is passed
+// with the character before it.
+func (v *View) cursorSteps(x, y int) int {
+	if y < 0 || y >= len(v.lines) || x < 0 {
+		return x
+	}
+	line := v.lines[y]
+	n := 0
+	if x > len(line) {
+		n, x = x-len(line), len(line)
+	}
+	for _, c := range line[:x] {
+		if runeWidth(c.chr) > 0 {
+			n++
+		}
+	}
+	return n
 }
@@ -0,0 +1,195 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+type escapeState int
+
+const (
+	stateNone escapeState = iota
+	stateEscape
+	stateCSI
+	stateOSC
+	stateOSCEscape
+)
+
+// escapeInterpreter interprets the ANSI escape sequences written to a view.
+// SGR sequences change the colors of the following runes, the other
+// sequences are dropped.
+type escapeInterpreter struct {
+	state  escapeState
+	params []int // parameters of the current CSI sequence, -1 if empty
+	param  int   // parameter being read, -1 if empty
+
+	fg, bg       Attribute
+	fgSet, bgSet bool      // if false, the colors of the view are used
+	styles       Attribute // bold, underline and reverse
+}
+
+// parseOne feeds a rune to the interpreter. It returns true if the rune
+// is part of an escape sequence, and so must not be written.
+func (ei *escapeInterpreter) parseOne(ch rune) bool {
+	switch ei.state {
+	case stateEscape:
+		switch ch {
+		case '[':
+			ei.state = stateCSI
+			ei.params = ei.params[:0]
+			ei.param = -1
+		case ']':
+			ei.state = stateOSC
+		default:
+			// two-character sequence, not supported
+			ei.state = stateNone
+		}
+		return true
+	case stateCSI:
+		switch {
+		case ch >= '0' && ch <= '9':
+			if ei.param < 0 {
+				ei.param = 0
+			}
+			ei.param = ei.param*10 + int(ch-'0')
+		case ch == ';':
+			ei.params = append(ei.params, ei.param)
+			ei.param = -1
+		case ch >= 0x40 && ch <= 0x7e:
+			// final byte
+			ei.params = append(ei.params, ei.param)
+			if ch == 'm' {
+				ei.sgr()
+			}
+			ei.state = stateNone
+		}
+		return true
+	case stateOSC:
+		switch ch {
+		case '\a':
+			ei.state = stateNone
+		case '\x1b':
+			ei.state = stateOSCEscape
+		}
+		return true
+	case stateOSCEscape:
+		// string terminator
+		ei.state = stateNone
+		return true
+	}
+
+	if ch == '\x1b' {
+		ei.state = stateEscape
+		return true
+	}
+	return false
+}
+
+// sgr applies the parameters of a "Select Graphic Rendition" sequence.
+func (ei *escapeInterpreter) sgr() {
+	for i := 0; i < len(ei.params); i++ {
+		p := ei.params[i]
+		switch {
+		case p <= 0:
+			ei.reset()
+		case p == 1:
+			ei.styles |= AttrBold
+		case p == 4:
+			ei.styles |= AttrUnderline
+		case p == 7:
+			ei.styles |= AttrReverse
+		case p == 22:
+			ei.styles &^= AttrBold
+		case p == 24:
+			ei.styles &^= AttrUnderline
+		case p == 27:
+			ei.styles &^= AttrReverse
+		case p >= 30 && p <= 37:
+			ei.fg, ei.fgSet = paletteColor(p-30), true
+		case p == 39:
+			ei.fgSet = false
+		case p >= 40 && p <= 47:
+			ei.bg, ei.bgSet = paletteColor(p-40), true
+		case p == 49:
+			ei.bgSet = false
+		case p >= 90 && p <= 97:
+			ei.fg, ei.fgSet = paletteColor(p-90+8), true
+		case p >= 100 && p <= 107:
+			ei.bg, ei.bgSet = paletteColor(p-100+8), true
+		case p == 38 || p == 48:
+			c, n, ok := ei.extendedColor(i + 1)
+			i += n
+			if !ok {
+				continue
+			}
+			if p == 38 {
+				ei.fg, ei.fgSet = c, true
+			} else {
+				ei.bg, ei.bgSet = c, true
+			}
+		}
+	}
+}
+
+// extendedColor parses the color of a "38" or "48" SGR parameter, whose
+// arguments start at the index i. It returns the color, the number of
+// arguments used and false if the color is not supported.
+func (ei *escapeInterpreter) extendedColor(i int) (c Attribute, n int, ok bool) {
+	if i >= len(ei.params) {
+		return 0, 0, false
+	}
+	switch ei.params[i] {
+	case 5:
+		if i+1 >= len(ei.params) {
+			return 0, len(ei.params) - i, false
+		}
+		idx := ei.params[i+1]
+		if idx < 0 || idx > 255 {
+			return 0, 2, false
+		}
+		return paletteColor(idx), 2, true
+	case 2:
+		if i+3 >= len(ei.params) {
+			return 0, len(ei.params) - i, false
+		}
+		r, g, b := ei.params[i+1], ei.params[i+2], ei.params[i+3]
+		if r < 0 || r > 255 || g < 0 || g > 255 || b < 0 || b > 255 {
+			return 0, 4, false
+		}
+		return ColorRGB(uint8(r), uint8(g), uint8(b)), 4, true
+	}
+	return 0, 1, false
+}
+
+// reset restores the colors of the view.
+func (ei *escapeInterpreter) reset() {
+	ei.fgSet, ei.bgSet = false, false
+	ei.styles = 0
+}
+
+// apply returns base with the colors selected by the escape sequences.
+// base is returned unchanged if no SGR attribute is active.
+func (ei *escapeInterpreter) apply(v *View, base cell) cell {
+	if !ei.fgSet && !ei.bgSet && ei.styles == 0 {
+		return base
+	}
+	fg, bg := v.FgColor, v.BgColor
+	if base.styled {
+		fg, bg = base.fgColor, base.bgColor
+	}
+	if ei.fgSet {
+		fg = ei.fg
+	}
+	if ei.bgSet {
+		bg = ei.bg
+	}
+	base.fgColor = fg | ei.styles
+	base.bgColor = bg
+	base.styled = true
+	return base
+}
+
+// paletteColor returns the Attribute of the given index of the 256 colors
+// palette.
+func paletteColor(idx int) Attribute {
+	return Color256(uint8(idx))
+}
@@ -0,0 +1,109 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEscapeSequences(t *testing.T) {
+	const fg, bg = ColorWhite, ColorBlack
+	type style struct {
+		x      int
+		fg, bg Attribute
+	}
+	tests := []struct {
+		name   string
+		writes []string
+		buffer string
+		styles []style
+	}{
+		{
+			name: "plain", writes: []string{"ab"}, buffer: "ab",
+			styles: []style{{0, fg, bg}, {1, fg, bg}},
+		},
+		{
+			name: "basic", writes: []string{"\x1b[31;42mab"}, buffer: "ab",
+			styles: []style{{0, Color256(1), Color256(2)}, {1, Color256(1), Color256(2)}},
+		},
+		{
+			name: "bright", writes: []string{"\x1b[91;104mx"}, buffer: "x",
+			styles: []style{{0, Color256(9), Color256(12)}},
+		},
+		{
+			name: "split parameters", writes: []string{"a\x1b[3", "2;4", "4mb"}, buffer: "ab",
+			styles: []style{{0, fg, bg}, {1, Color256(2), Color256(4)}},
+		},
+		{
+			name: "split introducer", writes: []string{"a\x1b", "[1mb"}, buffer: "ab",
+			styles: []style{{0, fg, bg}, {1, fg | AttrBold, bg}},
+		},
+		{
+			name: "256 colors", writes: []string{"\x1b[38;5;208;48;5;17mx"}, buffer: "x",
+			styles: []style{{0, Color256(208), Color256(17)}},
+		},
+		{
+			name: "24-bit colors", writes: []string{"\x1b[38;2;1;2;3mx\x1b[48;2;10;20;30my"}, buffer: "xy",
+			styles: []style{{0, ColorRGB(1, 2, 3), bg}, {1, ColorRGB(1, 2, 3), ColorRGB(10, 20, 30)}},
+		},
+		{
+			name: "split 24-bit color", writes: []string{"\x1b[38;2;1", ";2;3mx"}, buffer: "x",
+			styles: []style{{0, ColorRGB(1, 2, 3), bg}},
+		},
+		{
+			name: "invalid colors", writes: []string{"\x1b[38;5;300mx\x1b[48;2;1;2mx\x1b[38;9mx"}, buffer: "xxx",
+			styles: []style{{0, fg, bg}, {1, fg, bg}, {2, fg, bg}},
+		},
+		{
+			name: "styles", writes: []string{"\x1b[1;4;31mx\x1b[22my\x1b[24;7mz"}, buffer: "xyz",
+			styles: []style{
+				{0, Color256(1) | AttrBold | AttrUnderline, bg},
+				{1, Color256(1) | AttrUnderline, bg},
+				{2, Color256(1) | AttrReverse, bg},
+			},
+		},
+		{
+			name: "reset", writes: []string{"\x1b[31;41ma\x1b[0mb\x1b[32mc\x1b[md"}, buffer: "abcd",
+			styles: []style{{0, Color256(1), Color256(1)}, {1, fg, bg}, {2, Color256(2), bg}, {3, fg, bg}},
+		},
+		{
+			name: "default colors", writes: []string{"\x1b[31;41ma\x1b[39mb\x1b[49mc"}, buffer: "abc",
+			styles: []style{{0, Color256(1), Color256(1)}, {1, fg, Color256(1)}, {2, fg, bg}},
+		},
+		{
+			name: "unsupported CSI", writes: []string{"a\x1b[2Jb\x1b[10;5Hc\x1b[?25ld"}, buffer: "abcd",
+			styles: []style{{1, fg, bg}, {2, fg, bg}},
+		},
+		{
+			name: "OSC", writes: []string{"a\x1b]0;title\ab\x1b]8;;http://x\x1b\\c"}, buffer: "abc",
+			styles: []style{{1, fg, bg}, {2, fg, bg}},
+		},
+		{
+			name: "split OSC", writes: []string{"a\x1b]0;ti", "tle\x1b", "\\b"}, buffer: "ab",
+		},
+		{
+			name: "two-character sequence", writes: []string{"a\x1b7b"}, buffer: "ab",
+		},
+	}
+	for _, tt := range tests {
+		v := newView("v", 0, 0, 10, 10)
+		v.FgColor, v.BgColor = fg, bg
+		for _, w := range tt.writes {
+			fmt.Fprint(v, w)
+		}
+		if got := v.Buffer(); got != tt.buffer+"\n" {
+			t.Errorf("%s: buffer %q, want %q", tt.name, got, tt.buffer)
+		}
+		for _, s := range tt.styles {
+			gotFg, gotBg, err := v.Style(s.x, 0)
+			if err != nil {
+				t.Errorf("%s: style of %d: %v", tt.name, s.x, err)
+			} else if gotFg != s.fg || gotBg != s.bg {
+				t.Errorf("%s: style of %d is %v/%v, want %v/%v", tt.name, s.x, gotFg, gotBg, s.fg, s.bg)
+			}
+		}
+	}
+}
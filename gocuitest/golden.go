@@ -0,0 +1,106 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gocuitest provides golden-file snapshot testing of screens
+// rendered by gocui.
+//
+// A golden file holds the runes of the screen followed by its attribute
+// layer, as returned by SimulationScreen.String and
+// SimulationScreen.AttrString. Golden files live in the testdata directory
+// of the package under test and are regenerated by running the tests with
+// the -update flag:
+//
+//	go test -update
+package gocuitest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretto-editor/gocui"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+const (
+	runesHeader = "-- runes --\n"
+	attrsHeader = "-- attributes --\n"
+)
+
+// Snapshot returns the content of the golden file corresponding to the
+// last flushed frame of s.
+func Snapshot(s *gocui.SimulationScreen) string {
+	return runesHeader + s.String() + attrsHeader + s.AttrString()
+}
+
+// AssertScreen compares the last flushed frame of s against the golden
+// file testdata/name.golden. With -update, the golden file is written
+// instead.
+func AssertScreen(t testing.TB, name string, s *gocui.SimulationScreen) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+	got := Snapshot(s)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run the tests with -update to create it)", err)
+	}
+	if d := diff(string(want), got); d != "" {
+		t.Errorf("screen does not match %s:\n%s", path, d)
+	}
+}
+
+// AssertLayout renders layout on a screen of the given size and compares
+// the result against the golden file testdata/name.golden.
+func AssertLayout(t testing.TB, name string, width, height int, layout gocui.Handler) {
+	t.Helper()
+
+	h, err := gocui.NewHarness(width, height, layout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	AssertScreen(t, name, h.Screen)
+}
+
+// diff returns a description of the lines that differ between want and
+// got, or an empty string if they are equal.
+func diff(want, got string) string {
+	if want == got {
+		return ""
+	}
+	wl := strings.Split(want, "\n")
+	gl := strings.Split(got, "\n")
+
+	var buf bytes.Buffer
+	for i := 0; i < len(wl) || i < len(gl); i++ {
+		var w, g string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if w != g {
+			fmt.Fprintf(&buf, "line %d:\n  want: %q\n  got:  %q\n", i+1, w, g)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,255 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocuitest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretto-editor/gocui"
+)
+
+// view is a view created by the layout returned by views.
+type view struct {
+	name           string
+	x0, y0, x1, y1 int
+	init           func(v *gocui.View)
+}
+
+// views returns a layout creating vs.
+func views(vs ...view) gocui.Handler {
+	return func(g *gocui.Gui) error {
+		for _, w := range vs {
+			v, err := g.SetView(w.name, "", w.x0, w.y0, w.x1, w.y1)
+			if err == nil {
+				continue
+			}
+			if err != gocui.ErrUnknownView {
+				return err
+			}
+			if w.init != nil {
+				w.init(v)
+			}
+		}
+		return nil
+	}
+}
+
+// border returns a view initializer setting a uniform border of style s.
+func border(s gocui.BorderStyle) func(v *gocui.View) {
+	return func(v *gocui.View) {
+		v.Border = gocui.UniformBorder(s)
+	}
+}
+
+// segs returns segments of texts, without style.
+func segs(texts ...string) []gocui.Segment {
+	var ss []gocui.Segment
+	for _, t := range texts {
+		ss = append(ss, gocui.Segment{Text: t})
+	}
+	return ss
+}
+
+// bars returns a view initializer using b as title and footer bars.
+func bars(b gocui.Bar) func(v *gocui.View) {
+	return func(v *gocui.View) {
+		v.TitleBar = b
+		v.FooterBar = b
+	}
+}
+
+func TestAssertLayout(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		layout        gocui.Handler
+	}{
+		{
+			name: "corners", width: 13, height: 7,
+			layout: views(
+				view{"a", 0, 0, 6, 3, nil},
+				view{"b", 6, 0, 12, 3, nil},
+				view{"c", 0, 3, 4, 6, nil},
+				view{"d", 4, 3, 8, 6, nil},
+				view{"e", 8, 3, 12, 6, nil},
+			),
+		},
+		{
+			// single, double, rounded and thick borders meeting at a
+			// junction
+			name: "mixed-corners", width: 13, height: 7,
+			layout: views(
+				view{"a", 0, 0, 6, 3, border(gocui.BorderSingle)},
+				view{"b", 6, 0, 12, 3, border(gocui.BorderDouble)},
+				view{"c", 0, 3, 6, 6, border(gocui.BorderRounded)},
+				view{"d", 6, 3, 12, 6, border(gocui.BorderThick)},
+			),
+		},
+		{
+			// the corners without junction keep the lines of the sides
+			name: "open-corners", width: 13, height: 5,
+			layout: views(
+				view{"a", 0, 0, 6, 4, func(v *gocui.View) {
+					v.Border = gocui.Border{Top: gocui.BorderNone, Right: gocui.BorderDouble, Bottom: gocui.BorderRounded, Left: gocui.BorderRounded}
+				}},
+				view{"b", 6, 0, 12, 2, func(v *gocui.View) {
+					v.Border = gocui.Border{Top: gocui.BorderNone, Right: gocui.BorderThick, Bottom: gocui.BorderASCII, Left: gocui.BorderNone}
+				}},
+			),
+		},
+		{
+			name: "clipped-bars", width: 10, height: 4,
+			layout: views(view{"a", 0, 0, 9, 3, func(v *gocui.View) {
+				v.Title = "a very long title"
+				v.Footer = "a very long footer"
+				fmt.Fprint(v, "text")
+			}}),
+		},
+		{
+			name: "aligned-bars", width: 16, height: 9,
+			layout: views(
+				view{"left", 0, 0, 15, 2, bars(gocui.Bar{Left: segs("ab", "c")})},
+				view{"center", 0, 3, 15, 5, bars(gocui.Bar{Center: segs("ab", "c")})},
+				view{"right", 0, 6, 15, 8, bars(gocui.Bar{Right: segs("ab", "c")})},
+			),
+		},
+		{
+			// the center is truncated first, then the left group
+			name: "crowded-bars", width: 16, height: 6,
+			layout: views(
+				view{"a", 0, 0, 15, 2, bars(gocui.Bar{Left: segs("left"), Center: segs("center"), Right: segs("right")})},
+				view{"b", 0, 3, 15, 5, bars(gocui.Bar{Left: segs("left side"), Center: segs("mid"), Right: segs("right")})},
+			),
+		},
+		{
+			// a truncated footer whose wide runes do not fill the bar
+			// starts like the title
+			name: "wide-bars", width: 10, height: 3,
+			layout: views(view{"a", 0, 0, 9, 2, func(v *gocui.View) {
+				v.Title = "世界世界世"
+				v.Footer = "世界世界世"
+			}}),
+		},
+		{
+			name: "wrapped", width: 10, height: 7,
+			layout: views(view{"a", 0, 0, 9, 6, func(v *gocui.View) {
+				v.Wrap = true
+				v.WordWrap = true
+				fmt.Fprint(v, "the quick brown fox jumps")
+			}}),
+		},
+		{
+			name: "wide", width: 8, height: 3,
+			layout: views(view{"a", 0, 0, 7, 2, func(v *gocui.View) {
+				fmt.Fprint(v, "a世界b")
+			}}),
+		},
+	}
+	for _, tt := range tests {
+		AssertLayout(t, tt.name, tt.width, tt.height, tt.layout)
+	}
+}
+
+func TestAssertScreen(t *testing.T) {
+	h, err := gocui.NewHarness(10, 4, views(view{"a", 0, 0, 9, 3, func(v *gocui.View) {
+		v.Editable = true
+		v.Highlight = true
+		fmt.Fprint(v, "first\nsecond")
+	}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	if err := h.Gui.SetCurrentView("a"); err != nil {
+		t.Fatal(err)
+	}
+	h.Screen.InjectString("typed")
+	if err := h.Run(); err != nil {
+		t.Fatal(err)
+	}
+	AssertScreen(t, "typed", h.Screen)
+}
+
+// recorder is a testing.TB recording the failures instead of reporting
+// them.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatal(args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprint(args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gocuitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	defer func(u bool) { *update = u }(*update)
+
+	layout := func(text string) gocui.Handler {
+		return views(view{"a", 0, 0, 9, 2, func(v *gocui.View) {
+			fmt.Fprint(v, text)
+		}})
+	}
+
+	*update = false
+	r := &recorder{TB: t}
+	AssertLayout(r, "new", 10, 3, layout("old"))
+	if len(r.failures) == 0 || !strings.Contains(r.failures[0], "-update") {
+		t.Errorf("missing golden file: got failures %q", r.failures)
+	}
+
+	*update = true
+	r = &recorder{TB: t}
+	AssertLayout(r, "new", 10, 3, layout("old"))
+	if len(r.failures) > 0 {
+		t.Fatalf("update: got failures %q", r.failures)
+	}
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "new.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), runesHeader+"┌────────┐\n│old     │\n") {
+		t.Errorf("update wrote:\n%s", b)
+	}
+
+	*update = false
+	r = &recorder{TB: t}
+	AssertLayout(r, "new", 10, 3, layout("old"))
+	if len(r.failures) > 0 {
+		t.Errorf("same screen: got failures %q", r.failures)
+	}
+	r = &recorder{TB: t}
+	AssertLayout(r, "new", 10, 3, layout("new"))
+	if len(r.failures) != 1 || !strings.Contains(r.failures[0], "line 3") {
+		t.Errorf("different screen: got failures %q", r.failures)
+	}
+}
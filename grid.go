@@ -0,0 +1,150 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+// Align defines how a child smaller than its grid area is placed in it.
+type Align int
+
+// Alignments.
+const (
+	AlignStart Align = iota
+	AlignCenter
+	AlignEnd
+)
+
+// GridCell defines the area of a LayoutGrid covered by a child. Row and
+// Col are the indexes of its top-left track, RowSpan and ColSpan the
+// number of tracks it covers (0 means 1).
+//
+// By default the child covers the whole area. If Width or Height is set,
+// the child is given this size and placed in the area according to HAlign
+// or VAlign.
+type GridCell struct {
+	Row, Col         int
+	RowSpan, ColSpan int
+	Width, Height    int
+	HAlign, VAlign   Align
+}
+
+// grid holds the tracks of a LayoutGrid.
+type grid struct {
+	rows, cols []Constraint
+	gap        int // number of cells between two tracks
+}
+
+// SetGridLayout makes the viewNode with the given name place its children
+// on a grid. rows and cols define the sizes of the tracks of the grid,
+// with the same rules as the children of a linear layout. gap is the
+// number of cells between two tracks, 0 means that neighbours share the
+// edge between them.
+func (g *Gui) SetGridLayout(name string, rows, cols []Constraint, gap int) error {
+	c, err := g.ViewNode(name)
+	if err != nil {
+		return err
+	}
+	c.layout = LayoutGrid
+	c.grid = grid{rows: rows, cols: cols, gap: gap}
+	g.arrange()
+	return nil
+}
+
+// SetGridView creates a new view covering the given cell of the grid of
+// the viewNode father. If a view with the same name already exists, its
+// cell is updated; otherwise, the error ErrUnknownView is returned, which
+// allows to assert if the View must be initialized.
+func (g *Gui) SetGridView(name string, father string, cell GridCell) (*View, error) {
+	return g.setLayoutView(name, father, func(it *layoutItem) {
+		it.cell = cell
+	})
+}
+
+// SetGridViewNode creates a new viewNode covering the given cell of the
+// grid of the viewNode father. mode defines how the new viewNode places
+// its own children. If a viewNode with the same name already exists, its
+// cell and mode are updated; otherwise, the error ErrUnknownViewNode is
+// returned.
+func (g *Gui) SetGridViewNode(name string, father string, mode LayoutMode, cell GridCell) error {
+	return g.setLayoutViewNode(name, father, mode, func(it *layoutItem) {
+		it.cell = cell
+	})
+}
+
+// arrangeGrid places the children of c on its grid.
+func (c *Container) arrangeGrid() {
+	rows := tracks(c.y0, c.y1, c.grid.rows, c.grid.gap)
+	cols := tracks(c.x0, c.x1, c.grid.cols, c.grid.gap)
+
+	for _, it := range c.items {
+		node := c.child(it.name)
+		if node == nil {
+			continue
+		}
+		y0, y1, ok := span(rows, it.cell.Row, it.cell.RowSpan)
+		if !ok {
+			continue
+		}
+		x0, x1, ok := span(cols, it.cell.Col, it.cell.ColSpan)
+		if !ok {
+			continue
+		}
+		x0, x1 = align(x0, x1, it.cell.Width, it.cell.HAlign)
+		y0, y1 = align(y0, y1, it.cell.Height, it.cell.VAlign)
+		setPosition(node, x0, y0, x1, y1)
+	}
+}
+
+// track is the first and the last edge of a row or a column of a grid.
+type track struct {
+	start, end int
+}
+
+// tracks splits the interval [start, end] in tracks separated by gap
+// cells.
+func tracks(start, end int, cons []Constraint, gap int) []track {
+	if len(cons) == 0 {
+		return nil
+	}
+	sizes := distribute(end-start-gap*(len(cons)-1), cons)
+	ts := make([]track, len(cons))
+	pos := start
+	for i, size := range sizes {
+		ts[i] = track{start: pos, end: pos + size}
+		pos += size + gap
+	}
+	return ts
+}
+
+// span returns the edges of the area covering n tracks from the index
+// first. n is considered to be 1 if it is not positive, and the area is
+// truncated to the last track.
+func span(ts []track, first, n int) (start, end int, ok bool) {
+	if first < 0 || first >= len(ts) {
+		return 0, 0, false
+	}
+	if n < 1 {
+		n = 1
+	}
+	last := first + n - 1
+	if last >= len(ts) {
+		last = len(ts) - 1
+	}
+	return ts[first].start, ts[last].end, true
+}
+
+// align places an element of the given size in the interval [start, end].
+// The element covers the whole interval if size is not positive or does
+// not fit.
+func align(start, end, size int, a Align) (int, int) {
+	if size <= 0 || size >= end-start {
+		return start, end
+	}
+	switch a {
+	case AlignCenter:
+		start += (end - start - size) / 2
+	case AlignEnd:
+		start = end - size
+	}
+	return start, start + size
+}
@@ -0,0 +1,142 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTracks(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end int
+		cons       []Constraint
+		gap        int
+		want       []track
+	}{
+		{"none", 0, 10, nil, 0, nil},
+		{"shared edges", 0, 10, []Constraint{Flex(1), Flex(1)}, 0, []track{{0, 5}, {5, 10}}},
+		{"gap", 0, 10, []Constraint{Flex(1), Flex(1)}, 1, []track{{0, 4}, {5, 10}}},
+		{"offset", 3, 23, []Constraint{Fixed(5), Flex(1), Fixed(5)}, 2, []track{{3, 8}, {10, 16}, {18, 23}}},
+		{"percent", 0, 100, []Constraint{Percent(25), Flex(1)}, 0, []track{{0, 25}, {25, 100}}},
+	}
+	for _, tt := range tests {
+		if got := tracks(tt.start, tt.end, tt.cons, tt.gap); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSpan(t *testing.T) {
+	ts := []track{{0, 4}, {5, 10}, {11, 20}}
+	tests := []struct {
+		name       string
+		first, n   int
+		start, end int
+		ok         bool
+	}{
+		{"single", 1, 1, 5, 10, true},
+		{"default span", 1, 0, 5, 10, true},
+		{"over gap", 0, 2, 0, 10, true},
+		{"all", 0, 3, 0, 20, true},
+		{"truncated", 1, 5, 5, 20, true},
+		{"last truncated", 2, 2, 11, 20, true},
+		{"negative", -1, 1, 0, 0, false},
+		{"out of grid", 3, 1, 0, 0, false},
+	}
+	for _, tt := range tests {
+		start, end, ok := span(ts, tt.first, tt.n)
+		if start != tt.start || end != tt.end || ok != tt.ok {
+			t.Errorf("%s: got %d, %d, %v, want %d, %d, %v", tt.name, start, end, ok, tt.start, tt.end, tt.ok)
+		}
+	}
+}
+
+func TestAlign(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       int
+		a          Align
+		start, end int
+	}{
+		{"whole", 0, AlignCenter, 10, 20},
+		{"too big", 12, AlignEnd, 10, 20},
+		{"same size", 10, AlignEnd, 10, 20},
+		{"start", 4, AlignStart, 10, 14},
+		{"center", 4, AlignCenter, 13, 17},
+		{"center odd", 3, AlignCenter, 13, 16},
+		{"end", 4, AlignEnd, 16, 20},
+	}
+	for _, tt := range tests {
+		start, end := align(10, 20, tt.size, tt.a)
+		if start != tt.start || end != tt.end {
+			t.Errorf("%s: got %d, %d, want %d, %d", tt.name, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestGridLayout(t *testing.T) {
+	h, err := NewHarness(21, 9, func(g *Gui) error {
+		err := g.SetGridLayout("", []Constraint{Flex(1), Flex(1)}, []Constraint{Flex(1), Flex(1), Flex(1)}, 0)
+		if err != nil {
+			return err
+		}
+		cells := map[string]GridCell{
+			"wide":   {Row: 0, Col: 0, ColSpan: 2},
+			"tall":   {Row: 0, Col: 2, RowSpan: 5},
+			"small":  {Row: 1, Col: 0, Width: 4, Height: 3, HAlign: AlignCenter, VAlign: AlignEnd},
+			"hidden": {Row: 2, Col: 0},
+		}
+		for name, c := range cells {
+			if _, err := g.SetGridView(name, "", c); err != nil && err != ErrUnknownView {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	tests := []struct {
+		name           string
+		x0, y0, x1, y1 int
+	}{
+		{"wide", 0, 0, 12, 4},
+		{"tall", 12, 0, 20, 8},
+		{"small", 1, 5, 5, 8},
+	}
+	for _, tt := range tests {
+		x0, y0, x1, y1, err := h.Gui.ViewPosition(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x0 != tt.x0 || y0 != tt.y0 || x1 != tt.x1 || y1 != tt.y1 {
+			t.Errorf("%s: got %d,%d,%d,%d, want %d,%d,%d,%d", tt.name, x0, y0, x1, y1, tt.x0, tt.y0, tt.x1, tt.y1)
+		}
+	}
+
+	positions := []struct {
+		x, y int
+		name string
+	}{
+		{8, 2, "wide"},
+		{16, 6, "tall"},
+		{3, 6, "small"},
+	}
+	for _, p := range positions {
+		v, err := h.Gui.ViewByPosition(p.x, p.y)
+		if err != nil {
+			t.Errorf("%d,%d: %v", p.x, p.y, err)
+		} else if v.Name() != p.name {
+			t.Errorf("%d,%d: got view %q, want %q", p.x, p.y, v.Name(), p.name)
+		}
+	}
+	if _, err := h.Gui.ViewByPosition(3, 4); err == nil {
+		t.Error("3,4: got a view in the unused space of the grid")
+	}
+}
@@ -7,8 +7,6 @@ package gocui
 import (
 	"errors"
 	"fmt"
-
-	"github.com/nsf/termbox-go"
 )
 
 // Handler represents a handler that can be used to update or modify the GUI.
@@ -32,10 +30,18 @@ var (
 	ErrUnknowMode = errors.New("unknown mode")
 )
 
+// Default colors of the Gui, also used by SetTheme when the theme has no
+// default role.
+const (
+	defaultFgColor = ColorWhite
+	defaultBgColor = ColorBlack
+)
+
 // Gui represents the whole User Interface, including the views, layouts
 // and keybindings.
 type Gui struct {
-	tbEvents    chan termbox.Event
+	screen      Screen
+	events      chan Event
 	userEvents  chan userEvent
 	viewTree    *Container
 	currentView *View
@@ -43,6 +49,10 @@ type Gui struct {
 	modes       []*Mode
 	currentMode *Mode
 	maxX, maxY  int
+	drag        *edgeDrag
+	colorMode   ColorMode
+	theme       Theme
+	clipboard   *Clipboard
 
 	// workingView represents the view related to a file to work on
 	workingView *View
@@ -52,9 +62,15 @@ type Gui struct {
 	BgColor, FgColor Attribute
 
 	// SelBgColor and SelFgColor are used to configure the background and
-	// foreground colors of the selected line, when it is highlighted.
+	// foreground colors of the selected text of the views, and of their
+	// highlighted line if its colors are not set.
 	SelBgColor, SelFgColor Attribute
 
+	// FocusBgColor and FocusFgColor allow to configure the colors of the
+	// frame of the current view. The colors of the GUI are used for the
+	// ones left to ColorDefault.
+	FocusBgColor, FocusFgColor Attribute
+
 	// If Cursor is true then the cursor is enabled.
 	Cursor bool
 
@@ -73,16 +89,29 @@ func NewGui() *Gui {
 }
 
 // Init initializes the library. This function must be called before
-// any other functions.
-func (g *Gui) Init() error {
-	if err := termbox.Init(); err != nil {
+// any other functions. By default the GUI is drawn on the terminal, the
+// options allow to use another Screen.
+func (g *Gui) Init(opts ...Option) error {
+	g.screen = newTermboxScreen()
+	g.colorMode = ColorModeAuto
+	g.clipboard = &Clipboard{}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if err := g.screen.Init(); err != nil {
 		return err
 	}
-	g.tbEvents = make(chan termbox.Event, 20)
+	g.clipboard.screen = g.screen
+	g.clipboard.bindProvider()
+	if g.colorMode == ColorModeAuto {
+		g.colorMode = DetectColorMode()
+	}
+	g.colorMode = g.screen.SetColorMode(g.colorMode)
+	g.events = make(chan Event, 20)
 	g.userEvents = make(chan userEvent, 20)
-	g.maxX, g.maxY = termbox.Size()
-	g.BgColor = ColorBlack
-	g.FgColor = ColorWhite
+	g.maxX, g.maxY = g.screen.Size()
+	g.BgColor = defaultBgColor
+	g.FgColor = defaultFgColor
 	g.Editor = DefaultEditor
 
 	g.currentView = nil
@@ -129,10 +158,15 @@ func (g *Gui) AddMode(name string, openFunc modeHandler, closeFunc modeHandler)
 	g.modes = append(g.modes, CreateMode(name, openFunc, closeFunc))
 }
 
+// ColorMode returns the color mode used to display the GUI.
+func (g *Gui) ColorMode() ColorMode {
+	return g.colorMode
+}
+
 // Close finalizes the library. It should be called after a successful
 // initialization and when gocui is not needed anymore.
 func (g *Gui) Close() {
-	termbox.Close()
+	g.screen.Close()
 }
 
 // Size returns the terminal's size.
@@ -147,7 +181,16 @@ func (g *Gui) SetRune(x, y int, ch rune) error {
 	if x < 0 || y < 0 || x >= g.maxX || y >= g.maxY {
 		return errors.New("invalid point")
 	}
-	termbox.SetCell(x, y, ch, termbox.Attribute(g.FgColor), termbox.Attribute(g.BgColor))
+	g.screen.SetCell(x, y, ch, g.FgColor, g.BgColor)
+	return nil
+}
+
+// setRuneStyle works like SetRune, but applies the colors of s.
+func (g *Gui) setRuneStyle(x, y int, ch rune, s Style) error {
+	if x < 0 || y < 0 || x >= g.maxX || y >= g.maxY {
+		return errors.New("invalid point")
+	}
+	g.screen.SetCell(x, y, ch, s.Fg, s.Bg)
 	return nil
 }
 
@@ -157,8 +200,8 @@ func (g *Gui) Rune(x, y int) (rune, error) {
 	if x < 0 || y < 0 || x >= g.maxX || y >= g.maxY {
 		return ' ', errors.New("invalid point")
 	}
-	c := termbox.CellBuffer()[y*g.maxX+x]
-	return c.Ch, nil
+	ch, _, _ := g.screen.Cell(x, y)
+	return ch, nil
 }
 
 // SetView creates a new view with its top-left corner at (x0, y0)
@@ -184,8 +227,9 @@ func (g *Gui) SetView(name string, father string, x0, y0, x1, y1 int) (*View, er
 	}
 
 	v := newView(name, x0, y0, x1, y1)
-	v.BgColor, v.FgColor = g.BgColor, g.FgColor
-	v.SelBgColor, v.SelFgColor = g.SelBgColor, g.SelFgColor
+	v.screen = g.screen
+	v.clipboard = g.clipboard
+	g.applyTheme(v)
 	c, err := g.ViewNode(father)
 	if c == nil && err != ErrUnknownViewNode {
 		return nil, err
@@ -359,6 +403,7 @@ func deleteViewRecursive(c *Container, name string) error {
 	for i, node := range c.childrens {
 		if _, ok := node.(*View); ok && node.Name() == name {
 			c.childrens = append(c.childrens[:i], c.childrens[i+1:]...)
+			c.removeItem(name)
 			return nil
 		} else if cont, ok := node.(*Container); ok {
 			err := deleteViewRecursive(cont, name)
@@ -424,9 +469,14 @@ func (g *Gui) SetKeybinding(modeName string, viewName string, key interface{}, m
 // Execute executes the given handler. This function can be called safely from
 // a goroutine in order to update the GUI. It is important to note that it
 // won't be executed immediately, instead it will be added to the user events
-// queue.
+// queue. The handler is queued before Execute returns unless the queue is
+// full.
 func (g *Gui) Execute(h Handler) {
-	go func() { g.userEvents <- userEvent{h: h} }()
+	select {
+	case g.userEvents <- userEvent{h: h}:
+	default:
+		go func() { g.userEvents <- userEvent{h: h} }()
+	}
 }
 
 // SetLayout sets the current layout. A layout is a function that
@@ -434,7 +484,7 @@ func (g *Gui) Execute(h Handler) {
 // the base views and its initializations.
 func (g *Gui) SetLayout(layout Handler) {
 	g.layout = layout
-	go func() { g.tbEvents <- termbox.Event{Type: termbox.EventResize} }()
+	go func() { g.events <- Event{Type: EventResize} }()
 }
 
 // MainLoop runs the main loop until an error is returned. A successful
@@ -442,15 +492,11 @@ func (g *Gui) SetLayout(layout Handler) {
 func (g *Gui) MainLoop() error {
 	go func() {
 		for {
-			g.tbEvents <- termbox.PollEvent()
+			g.events <- g.screen.PollEvent()
 		}
 	}()
 
-	inputMode := termbox.InputEsc
-	if g.Mouse {
-		inputMode |= termbox.InputMouse
-	}
-	termbox.SetInputMode(inputMode)
+	g.screen.SetMouse(g.Mouse)
 
 	if err := g.flush(); err != nil {
 		return err
@@ -458,7 +504,7 @@ func (g *Gui) MainLoop() error {
 	for {
 
 		select {
-		case ev := <-g.tbEvents:
+		case ev := <-g.events:
 			if err := g.handleEvent(&ev); err != nil {
 				return err
 			}
@@ -481,7 +527,7 @@ func (g *Gui) MainLoop() error {
 func (g *Gui) consumeevents() error {
 	for {
 		select {
-		case ev := <-g.tbEvents:
+		case ev := <-g.events:
 			if err := g.handleEvent(&ev); err != nil {
 				return err
 			}
@@ -497,11 +543,11 @@ func (g *Gui) consumeevents() error {
 
 // handleEvent handles an event, based on its type (key-press, error,
 // etc.)
-func (g *Gui) handleEvent(ev *termbox.Event) error {
+func (g *Gui) handleEvent(ev *Event) error {
 	switch ev.Type {
-	case termbox.EventKey, termbox.EventMouse:
+	case EventKey, EventMouse:
 		return g.onKey(ev)
-	case termbox.EventError:
+	case EventError:
 		return ev.Err
 	default:
 		return nil
@@ -514,9 +560,9 @@ func (g *Gui) flush() error {
 		return errors.New("Null layout")
 	}
 
-	termbox.Clear(termbox.Attribute(g.FgColor), termbox.Attribute(g.BgColor))
+	g.screen.Clear(g.FgColor, g.BgColor)
 
-	maxX, maxY := termbox.Size()
+	maxX, maxY := g.screen.Size()
 	// if GUI's size has changed, we need to redraw all views
 	if maxX != g.maxX || maxY != g.maxY {
 		updateViews(g.viewTree)
@@ -526,13 +572,20 @@ func (g *Gui) flush() error {
 	if err := g.layout(g); err != nil {
 		return err
 	}
+	g.arrange()
 	g.displayViews(g.viewTree)
 
+	// the frame of the current view is drawn again over the shared edges
+	if v := g.currentView; v != nil && v.Frame && !v.Hidden {
+		if err := g.drawFrame(v, true); err != nil {
+			return err
+		}
+	}
+
 	if err := g.drawIntersections(); err != nil {
 		return err
 	}
-	termbox.Flush()
-	return nil
+	return g.screen.Flush()
 }
 
 func updateViews(c *Container) {
@@ -549,18 +602,14 @@ func (g *Gui) displayViews(c *Container) error {
 	for _, node := range c.childrens {
 		if v, ok := node.(*View); ok && !v.Hidden {
 			if v.Frame {
-				if err := g.drawFrame(v); err != nil {
+				if err := g.drawFrame(v, false); err != nil {
 					return err
 				}
-				if v.Title != "" {
-					if err := g.drawTitle(v); err != nil {
-						return err
-					}
+				if err := g.drawTitle(v); err != nil {
+					return err
 				}
-				if v.Footer != "" {
-					if err := g.drawFooter(v); err != nil {
-						return err
-					}
+				if err := g.drawFooter(v); err != nil {
+					return err
 				}
 			}
 
@@ -576,76 +625,68 @@ func (g *Gui) displayViews(c *Container) error {
 	return nil
 }
 
-// drawFrame draws the horizontal and vertical edges of a view.
-func (g *Gui) drawFrame(v *View) error {
-	for x := v.x0 + 1; x < v.x1 && x < g.maxX; x++ {
-		if x < 0 {
-			continue
+// drawFrame draws the sides of the frame of a view, with their border
+// style. A side whose neighbour is not drawn covers the corner between
+// them. If overlay is true, only the cells already holding lines are
+// drawn, so that the titles of other views are kept.
+func (g *Gui) drawFrame(v *View, overlay bool) error {
+	style := g.frameStyle(v)
+	set := func(x, y int, ch rune, horizontal bool) error {
+		if x < 0 || y < 0 || x >= g.maxX || y >= g.maxY {
+			return nil
 		}
-		if v.y0 > -1 && v.y0 < g.maxY {
-			if err := g.SetRune(x, v.y0, '─'); err != nil {
-				return err
+		if overlay {
+			cur, _ := g.Rune(x, y)
+			a, ok := boxRunes[cur]
+			if !ok || a.up == lineASCII || a.left == lineASCII {
+				return nil
 			}
-		}
-		if v.y1 > -1 && v.y1 < g.maxY {
-			if err := g.SetRune(x, v.y1, '─'); err != nil {
-				return err
+			if horizontal && a.left == lineNone && a.right == lineNone ||
+				!horizontal && a.up == lineNone && a.down == lineNone {
+				return nil
 			}
 		}
+		return g.setRuneStyle(x, y, ch, style)
 	}
-	for y := v.y0 + 1; y < v.y1 && y < g.maxY; y++ {
-		if y < 0 {
-			continue
-		}
-		if v.x0 > -1 && v.x0 < g.maxX {
-			if err := g.SetRune(v.x0, y, '│'); err != nil {
+
+	b := v.Border
+	x0, x1 := v.x0+1, v.x1-1
+	if b.Left == BorderNone {
+		x0 = v.x0
+	}
+	if b.Right == BorderNone {
+		x1 = v.x1
+	}
+	for x := x0; x <= x1 && x < g.maxX; x++ {
+		if b.Top != BorderNone {
+			if err := set(x, v.y0, b.Top.horizontal(), true); err != nil {
 				return err
 			}
 		}
-		if v.x1 > -1 && v.x1 < g.maxX {
-			if err := g.SetRune(v.x1, y, '│'); err != nil {
+		if b.Bottom != BorderNone {
+			if err := set(x, v.y1, b.Bottom.horizontal(), true); err != nil {
 				return err
 			}
 		}
 	}
-	return nil
-}
-
-// drawTitle draws the title of the view.
-func (g *Gui) drawTitle(v *View) error {
-	if v.y0 < 0 || v.y0 >= g.maxY {
-		return nil
-	}
 
-	for i, ch := range v.Title {
-		x := v.x0 + i + 2
-		if x < 0 {
-			continue
-		} else if x > v.x1-2 || x >= g.maxX {
-			break
-		}
-		if err := g.SetRune(x, v.y0, ch); err != nil {
-			return err
-		}
+	y0, y1 := v.y0+1, v.y1-1
+	if b.Top == BorderNone {
+		y0 = v.y0
 	}
-	return nil
-}
-
-// drawFooter draws the footer of the view.
-func (g *Gui) drawFooter(v *View) error {
-	if v.y1 < 0 || v.y1 >= g.maxY {
-		return nil
+	if b.Bottom == BorderNone {
+		y1 = v.y1
 	}
-
-	for i, ch := range v.Footer {
-		x := v.x1 + i - 2 - len(v.Footer)
-		if x < 0 {
-			continue
-		} else if x > v.x1-2 || x >= g.maxX {
-			break
+	for y := y0; y <= y1 && y < g.maxY; y++ {
+		if b.Left != BorderNone {
+			if err := set(v.x0, y, b.Left.vertical(), false); err != nil {
+				return err
+			}
 		}
-		if err := g.SetRune(x, v.y1, ch); err != nil {
-			return err
+		if b.Right != BorderNone {
+			if err := set(v.x1, y, b.Right.vertical(), false); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -655,7 +696,7 @@ func (g *Gui) drawFooter(v *View) error {
 func (g *Gui) draw(v geom) error {
 	if g.Cursor {
 		if v := g.currentView; v != nil {
-			vMaxX, vMaxY := v.Size()
+			vMaxX, vMaxY := v.textSize()
 			if v.cx < 0 {
 				v.cx = 0
 			} else if v.cx >= vMaxX {
@@ -668,15 +709,15 @@ func (g *Gui) draw(v geom) error {
 			}
 
 			gMaxX, gMaxY := g.Size()
-			cx, cy := v.x0+v.cx+1, v.y0+v.cy+1
+			cx, cy := v.x0+v.gutterWidth()+v.cx+1, v.y0+v.cy+1
 			if cx >= 0 && cx < gMaxX && cy >= 0 && cy < gMaxY {
-				termbox.SetCursor(cx, cy)
+				g.screen.SetCursor(cx, cy)
 			} else {
-				termbox.HideCursor()
+				g.screen.HideCursor()
 			}
 		}
 	} else {
-		termbox.HideCursor()
+		g.screen.HideCursor()
 	}
 
 	if a, ok := v.(*View); ok {
@@ -697,25 +738,8 @@ func (g *Gui) drawIntersections() error {
 func (g *Gui) drawIntersectionsRecursively(c *Container) error {
 	for _, node := range c.childrens {
 		if v, ok := node.(*View); ok {
-			if ch, ok := g.intersectionRune(v.x0, v.y0); ok {
-				if err := g.SetRune(v.x0, v.y0, ch); err != nil {
-					return err
-				}
-			}
-			if ch, ok := g.intersectionRune(v.x0, v.y1); ok {
-				if err := g.SetRune(v.x0, v.y1, ch); err != nil {
-					return err
-				}
-			}
-			if ch, ok := g.intersectionRune(v.x1, v.y0); ok {
-				if err := g.SetRune(v.x1, v.y0, ch); err != nil {
-					return err
-				}
-			}
-			if ch, ok := g.intersectionRune(v.x1, v.y1); ok {
-				if err := g.SetRune(v.x1, v.y1, ch); err != nil {
-					return err
-				}
+			if err := g.drawCorners(v); err != nil {
+				return err
 			}
 		} else if cont, ok := node.(*Container); ok {
 			err := g.drawIntersectionsRecursively(cont)
@@ -727,95 +751,127 @@ func (g *Gui) drawIntersectionsRecursively(c *Container) error {
 	return nil
 }
 
+// drawCorners draws the intersections at the corners of a view. Rounded
+// borders get rounded corners when no other edge joins them. An
+// intersection on the frame of the current view takes its colors.
+func (g *Gui) drawCorners(v *View) error {
+	b := v.Border
+	corners := []struct {
+		x, y   int
+		h, vrt BorderStyle
+	}{
+		{v.x0, v.y0, b.Top, b.Left},
+		{v.x0, v.y1, b.Bottom, b.Left},
+		{v.x1, v.y0, b.Top, b.Right},
+		{v.x1, v.y1, b.Bottom, b.Right},
+	}
+	for _, c := range corners {
+		ch, ok := g.intersectionRune(c.x, c.y)
+		if !ok {
+			continue
+		}
+		if v.Frame && c.h == BorderRounded && c.vrt == BorderRounded {
+			if r, ok := roundedCorners[ch]; ok {
+				ch = r
+			}
+		}
+		style := g.frameStyle(v)
+		if cv := g.currentView; cv != nil && cv.onFrame(c.x, c.y) {
+			style = g.frameStyle(cv)
+		}
+		if err := g.setRuneStyle(c.x, c.y, ch, style); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // intersectionRune returns the correct intersection rune at a given
-// point.
+// point, joining the lines of the neighbouring cells. No rune is returned
+// if less than two lines join, or if they form a straight line.
 func (g *Gui) intersectionRune(x, y int) (rune, bool) {
 	if x < 0 || y < 0 || x >= g.maxX || y >= g.maxY {
 		return ' ', false
 	}
 
 	chTop, _ := g.Rune(x, y-1)
-	top := verticalRune(chTop)
 	chBottom, _ := g.Rune(x, y+1)
-	bottom := verticalRune(chBottom)
 	chLeft, _ := g.Rune(x-1, y)
-	left := horizontalRune(chLeft)
 	chRight, _ := g.Rune(x+1, y)
-	right := horizontalRune(chRight)
-
-	var ch rune
-	switch {
-	case !top && bottom && !left && right:
-		ch = '┌'
-	case !top && bottom && left && !right:
-		ch = '┐'
-	case top && !bottom && !left && right:
-		ch = '└'
-	case top && !bottom && left && !right:
-		ch = '┘'
-	case top && bottom && left && right:
-		ch = '┼'
-	case top && bottom && !left && right:
-		ch = '├'
-	case top && bottom && left && !right:
-		ch = '┤'
-	case !top && bottom && left && right:
-		ch = '┬'
-	case top && !bottom && left && right:
-		ch = '┴'
-	default:
+	a := boxArms{
+		up:    verticalRune(chTop, true),
+		down:  verticalRune(chBottom, false),
+		left:  horizontalRune(chLeft, true),
+		right: horizontalRune(chRight, false),
+	}
+
+	vertical := a.up != lineNone || a.down != lineNone
+	horizontal := a.left != lineNone || a.right != lineNone
+	if !vertical || !horizontal {
 		return ' ', false
 	}
-	return ch, true
+	return junctionRune(a)
 }
 
-// verticalRune returns if the given character is a vertical rune.
-func verticalRune(ch rune) bool {
-	if ch == '│' || ch == '┼' || ch == '├' || ch == '┤' {
-		return true
+// verticalRune returns the weight of the line of the given character
+// going down if down is true, going up otherwise.
+func verticalRune(ch rune, down bool) lineWeight {
+	if down {
+		return boxRunes[ch].down
 	}
-	return false
+	return boxRunes[ch].up
 }
 
-// verticalRune returns if the given character is a horizontal rune.
-func horizontalRune(ch rune) bool {
-	if ch == '─' || ch == '┼' || ch == '┬' || ch == '┴' {
-		return true
+// horizontalRune returns the weight of the line of the given character
+// going right if right is true, going left otherwise.
+func horizontalRune(ch rune, right bool) lineWeight {
+	if right {
+		return boxRunes[ch].right
 	}
-	return false
+	return boxRunes[ch].left
 }
 
 // onKey manages key-press events. A keybinding handler is called when
 // a key-press or mouse event satisfies a configured keybinding. Furthermore,
 // currentView's internal buffer is modified if currentView.Editable is true.
-func (g *Gui) onKey(ev *termbox.Event) error {
+func (g *Gui) onKey(ev *Event) error {
 	var curView *View
 
 	switch ev.Type {
-	case termbox.EventKey:
+	case EventKey:
 		if g.currentView != nil && g.currentView.Editable && g.Editor != nil {
-			g.Editor.Edit(g.currentView, Key(ev.Key), ev.Ch, Modifier(ev.Mod))
+			g.Editor.Edit(g.currentView, ev.Key, ev.Ch, ev.Mod)
 		}
 		curView = g.currentView
-	case termbox.EventMouse:
+	case EventMouse:
+		if g.onDrag(ev) {
+			return nil
+		}
 		mx, my := ev.MouseX, ev.MouseY
 		v, err := g.ViewByPosition(mx, my)
 		if err != nil {
 			break
 		}
-		if err := v.SetCursor(mx-v.x0-1, my-v.y0-1); err != nil {
+		x := mx - v.x0 - 1 - v.gutterWidth()
+		if x < 0 {
+			// a click on the gutter moves the cursor to the first column
+			x = 0
+		}
+		if err := v.SetCursor(x, my-v.y0-1); err != nil {
 			return err
 		}
 		curView = v
 	}
 
-	for _, kb := range g.currentMode.keybindings {
-		if kb.h == nil {
-			continue
-		}
-		if kb.matchKeypress(Key(ev.Key), ev.Ch, Modifier(ev.Mod)) && kb.matchView(g.viewTree, curView) {
-			if err := kb.h(g, curView); err != nil {
-				return err
+	if g.currentMode != nil {
+		for _, kb := range g.currentMode.keybindings {
+			if kb.h == nil {
+				continue
+			}
+			if kb.matchKeypress(ev.Key, ev.Ch, ev.Mod) && kb.matchView(g.viewTree, curView) {
+				if err := kb.h(g, curView); err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -0,0 +1,115 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import "strconv"
+
+// LineNumbers defines how the numbers of the lines of a view are displayed.
+type LineNumbers int
+
+// Line numbering modes.
+const (
+	// LineNumbersNone does not display line numbers.
+	LineNumbersNone LineNumbers = iota
+
+	// LineNumbersAbsolute displays the number of each line, starting at 1.
+	LineNumbersAbsolute
+
+	// LineNumbersRelative displays the distance between each line and the
+	// line of the cursor.
+	LineNumbersRelative
+
+	// LineNumbersHybrid displays the number of the line of the cursor and
+	// the distance to it for the other lines.
+	LineNumbersHybrid
+)
+
+// The gutter is made of the columns on the left of a view which are not
+// used by its text: the sign column, the line numbers followed by a space,
+// then the wrap markers.
+
+// numberWidth returns the number of columns used by the line numbers,
+// including the space after them.
+func (v *View) numberWidth() int {
+	if v.LineNumbers == LineNumbersNone {
+		return 0
+	}
+	n := len(v.lines)
+	if n < 1 {
+		n = 1
+	}
+	return len(strconv.Itoa(n)) + 1
+}
+
+// gutterWidth returns the number of columns on the left of the view which
+// are not used by its text.
+func (v *View) gutterWidth() int {
+	w := v.signWidth() + v.numberWidth()
+	if v.Wrap && v.WrapMarker != 0 {
+		w++
+	}
+	return w
+}
+
+// textSize returns the number of columns and rows of the view used by its
+// text.
+func (v *View) textSize() (x, y int) {
+	x, y = v.Size()
+	if x -= v.gutterWidth(); x < 0 {
+		x = 0
+	}
+	return x, y
+}
+
+// lineNumber returns the number displayed in the gutter for the line y
+// of the internal buffer, cy being the line of the cursor.
+func (v *View) lineNumber(y, cy int) int {
+	switch {
+	case v.LineNumbers == LineNumbersAbsolute:
+		return y + 1
+	case v.LineNumbers == LineNumbersHybrid && y == cy:
+		return y + 1
+	case y < cy:
+		return cy - y
+	default:
+		return y - cy
+	}
+}
+
+// drawGutter draws the gutter of the row y of the view, displaying vline.
+// cy is the line of the internal buffer under the cursor. Only the first
+// line of the view of a wrapped line is numbered and shows its signs.
+func (v *View) drawGutter(y int, vline viewLine, cy int) {
+	if v.SignColumn && vline.linesX == 0 {
+		v.drawSign(y, vline.linesY)
+	}
+
+	x0 := v.x0 + v.signWidth()
+	maxX := v.x1 - x0 - 1
+	fg, bg := v.FgColor, v.BgColor
+	if v.NumberFgColor != ColorDefault {
+		fg = v.NumberFgColor
+	}
+	if v.NumberBgColor != ColorDefault {
+		bg = v.NumberBgColor
+	}
+
+	nw := v.numberWidth()
+	for x := 0; x < nw && x < maxX; x++ {
+		v.screen.SetCell(x0+x+1, v.y0+y+1, ' ', fg, bg)
+	}
+	if nw > 0 && vline.linesX == 0 {
+		num := strconv.Itoa(v.lineNumber(vline.linesY, cy))
+		for i, ch := range num {
+			if x := nw - 1 - len(num) + i; x >= 0 && x < maxX {
+				v.screen.SetCell(x0+x+1, v.y0+y+1, ch, fg, bg)
+			}
+		}
+	}
+
+	if v.Wrap && v.WrapMarker != 0 && vline.linesX > 0 && nw < maxX {
+		v.screen.SetCell(x0+nw+1, v.y0+y+1, v.WrapMarker, v.FgColor, v.BgColor)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// numberedText returns n lines holding "xyz".
+func numberedText(n int) string {
+	return strings.TrimSuffix(strings.Repeat("xyz\n", n), "\n")
+}
+
+func TestLineNumbers(t *testing.T) {
+	tests := []struct {
+		mode LineNumbers
+		want string
+	}{
+		{LineNumbersAbsolute, lines("┌──────┐", "│1 a   │", "│2 b   │", "│3 c   │", "│4 d   │", "└──────┘")},
+		{LineNumbersRelative, lines("┌──────┐", "│2 a   │", "│1 b   │", "│0 c   │", "│1 d   │", "└──────┘")},
+		{LineNumbersHybrid, lines("┌──────┐", "│2 a   │", "│1 b   │", "│3 c   │", "│1 d   │", "└──────┘")},
+	}
+	for _, tt := range tests {
+		var v *View
+		h, err := NewHarness(8, 6, testLayout(viewSpec{"a", 0, 0, 7, 5, func(nv *View) {
+			v = nv
+			v.LineNumbers = tt.mode
+			fmt.Fprint(v, "a\nb\nc\nd")
+		}}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		v.AbsMoveCursor(0, 2, false)
+		if err := h.Draw(); err != nil {
+			t.Fatal(err)
+		}
+		if got := h.Screen.String(); got != tt.want {
+			t.Errorf("mode %d: got\n%swant\n%s", tt.mode, got, tt.want)
+		}
+		h.Close()
+	}
+}
+
+func TestGutterWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines int
+		init  func(v *View)
+		width int
+	}{
+		{"no numbers", 10, func(v *View) {}, 0},
+		{"1 line", 1, func(v *View) { v.LineNumbers = LineNumbersAbsolute }, 2},
+		{"9 lines", 9, func(v *View) { v.LineNumbers = LineNumbersAbsolute }, 2},
+		{"10 lines", 10, func(v *View) { v.LineNumbers = LineNumbersRelative }, 3},
+		{"99 lines", 99, func(v *View) { v.LineNumbers = LineNumbersHybrid }, 3},
+		{"100 lines", 100, func(v *View) { v.LineNumbers = LineNumbersAbsolute }, 4},
+		{"signs", 10, func(v *View) {
+			v.LineNumbers = LineNumbersAbsolute
+			v.SignColumn = true
+		}, signColumnWidth + 3},
+		{"wrap marker", 10, func(v *View) {
+			v.LineNumbers = LineNumbersAbsolute
+			v.Wrap = true
+			v.WrapMarker = '↪'
+		}, 4},
+	}
+	for _, tt := range tests {
+		v := newView("v", 0, 0, 20, 10)
+		tt.init(v)
+		fmt.Fprint(v, numberedText(tt.lines))
+		if got := v.gutterWidth(); got != tt.width {
+			t.Errorf("%s: gutter width %d, want %d", tt.name, got, tt.width)
+		}
+	}
+}
+
+func TestLineNumbersGrowth(t *testing.T) {
+	var v *View
+	h, err := NewHarness(8, 4, testLayout(viewSpec{"a", 0, 0, 7, 3, func(nv *View) {
+		v = nv
+		v.LineNumbers = LineNumbersAbsolute
+		fmt.Fprint(v, numberedText(9))
+	}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	v.AbsMoveCursor(0, 8, false)
+	if err := h.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := h.Screen.String(), lines("┌──────┐", "│8 xyz │", "│9 xyz │", "└──────┘"); got != want {
+		t.Errorf("9 lines: got\n%swant\n%s", got, want)
+	}
+
+	// the numbers are aligned on the right when the tenth line is added
+	fmt.Fprint(v, "\nxyz")
+	if err := h.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := h.Screen.String(), lines("┌──────┐", "│ 8 xyz│", "│ 9 xyz│", "└──────┘"); got != want {
+		t.Errorf("10 lines: got\n%swant\n%s", got, want)
+	}
+}
+
+func TestGutterCursor(t *testing.T) {
+	var v *View
+	h, err := NewHarness(12, 5, testLayout(viewSpec{"a", 0, 0, 11, 4, func(nv *View) {
+		v = nv
+		v.LineNumbers = LineNumbersAbsolute
+		fmt.Fprint(v, numberedText(10))
+	}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.Gui.Cursor = true
+
+	// the cursor is displayed after the gutter
+	v.AbsMoveCursor(1, 0, false)
+	if err := h.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	if x, y, _ := h.Screen.CursorPosition(); x != 5 || y != 1 {
+		t.Errorf("cursor at %d,%d, want 5,1", x, y)
+	}
+
+	// a click on the text moves the cursor to the clicked column
+	h.Screen.InjectMouse(6, 2, MouseLeft, ModNone)
+	if err := h.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if cx, cy := v.Cursor(); cx != 2 || cy != 1 {
+		t.Errorf("cursor (%d, %d) after a click on the text, want (2, 1)", cx, cy)
+	}
+
+	// a click on the gutter moves the cursor to the first column
+	h.Screen.InjectMouse(2, 3, MouseLeft, ModNone)
+	if err := h.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if cx, cy := v.Cursor(); cx != 0 || cy != 2 {
+		t.Errorf("cursor (%d, %d) after a click on the gutter, want (0, 2)", cx, cy)
+	}
+	if x, y, _ := h.Screen.CursorPosition(); x != 4 || y != 3 {
+		t.Errorf("cursor at %d,%d, want 4,3", x, y)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+// Harness drives a Gui drawn on a SimulationScreen without running
+// MainLoop. Events injected in the screen are handled one at a time, and
+// the GUI is redrawn after each of them, like MainLoop does. It is meant
+// to be used in tests:
+//
+//	h, err := gocui.NewHarness(80, 24, layout)
+//	if err != nil {
+//		// handle error
+//	}
+//	h.Screen.InjectString("hello")
+//	if err := h.Run(); err != nil {
+//		// handle error
+//	}
+//	fmt.Print(h.Screen)
+type Harness struct {
+	Gui    *Gui
+	Screen *SimulationScreen
+}
+
+// NewHarness initializes a Gui on a SimulationScreen of the given size,
+// sets its layout and draws the first frame. The screen supports 24-bit
+// colors unless another mode is given in opts.
+func NewHarness(width, height int, layout Handler, opts ...Option) (*Harness, error) {
+	s := NewSimulationScreen(width, height)
+	g := NewGui()
+	opts = append([]Option{WithColorMode(ColorModeTrueColor)}, opts...)
+	opts = append(opts, WithScreen(s))
+	if err := g.Init(opts...); err != nil {
+		return nil, err
+	}
+	// the layout is set directly, SetLayout would queue an asynchronous
+	// resize event
+	g.layout = layout
+
+	h := &Harness{Gui: g, Screen: s}
+	if err := h.Draw(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Draw redraws the GUI.
+func (h *Harness) Draw() error {
+	return h.Gui.flush()
+}
+
+// Step handles the next injected event, then the handlers queued by
+// Gui.Execute, like MainLoop does, and redraws the GUI. It returns false
+// if there was neither a pending event nor a queued handler.
+func (h *Harness) Step() (bool, error) {
+	ev, ok := h.Screen.nextEvent()
+	if ok {
+		if err := h.Gui.handleEvent(&ev); err != nil {
+			return true, err
+		}
+	}
+	n, err := h.executeQueued()
+	if err != nil {
+		return true, err
+	}
+	if !ok && n == 0 {
+		return false, nil
+	}
+	return true, h.Draw()
+}
+
+// executeQueued executes the handlers queued by Gui.Execute, including the
+// ones they queue, and returns how many have been executed.
+func (h *Harness) executeQueued() (int, error) {
+	for n := 0; ; n++ {
+		select {
+		case ev := <-h.Gui.userEvents:
+			if err := ev.h(h.Gui); err != nil {
+				return n + 1, err
+			}
+		default:
+			return n, nil
+		}
+	}
+}
+
+// Run handles all the pending events, one at a time.
+func (h *Harness) Run() error {
+	for {
+		ok, err := h.Step()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+	}
+}
+
+// Execute calls handler and redraws the GUI. Unlike Gui.Execute, the
+// handler is executed immediately.
+func (h *Harness) Execute(handler Handler) error {
+	if err := handler(h.Gui); err != nil {
+		return err
+	}
+	return h.Draw()
+}
+
+// Close finalizes the Gui.
+func (h *Harness) Close() {
+	h.Gui.Close()
+}
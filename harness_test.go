@@ -0,0 +1,431 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// viewSpec describes a view created by the layout of testLayout.
+type viewSpec struct {
+	name           string
+	x0, y0, x1, y1 int
+	init           func(v *View)
+}
+
+// testLayout returns a layout creating the views of specs, the first one
+// being the current view.
+func testLayout(specs ...viewSpec) Handler {
+	return func(g *Gui) error {
+		for _, s := range specs {
+			v, err := g.SetView(s.name, "", s.x0, s.y0, s.x1, s.y1)
+			if err == nil {
+				continue
+			}
+			if err != ErrUnknownView {
+				return err
+			}
+			if s.init != nil {
+				s.init(v)
+			}
+			if g.CurrentView() == nil {
+				if err := g.SetCurrentView(s.name); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// lines joins lines, each of them followed by a line break, like
+// SimulationScreen.String does.
+func lines(ls ...string) string {
+	return strings.Join(ls, "\n") + "\n"
+}
+
+func TestHarnessDraw(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		views         []viewSpec
+		want          string
+	}{
+		{
+			name: "frame and title", width: 12, height: 4,
+			views: []viewSpec{{"a", 0, 0, 11, 3, func(v *View) {
+				v.Title = "A"
+				fmt.Fprint(v, "hello\nworld")
+			}}},
+			want: lines(
+				"┌─A────────┐",
+				"│hello     │",
+				"│world     │",
+				"└──────────┘",
+			),
+		},
+		{
+			name: "clipped content", width: 8, height: 4,
+			views: []viewSpec{{"a", 0, 0, 7, 3, func(v *View) {
+				fmt.Fprint(v, "abcdefghij\n1\n2\n3")
+			}}},
+			want: lines(
+				"┌──────┐",
+				"│abcdef│",
+				"│1     │",
+				"└──────┘",
+			),
+		},
+		{
+			name: "wrap", width: 8, height: 5,
+			views: []viewSpec{{"a", 0, 0, 7, 4, func(v *View) {
+				v.Wrap = true
+				fmt.Fprint(v, "abcdefghij\nxy")
+			}}},
+			want: lines(
+				"┌──────┐",
+				"│abcde │",
+				"│fghij │",
+				"│xy    │",
+				"└──────┘",
+			),
+		},
+		{
+			name: "side by side", width: 9, height: 3,
+			views: []viewSpec{
+				{"a", 0, 0, 4, 2, nil},
+				{"b", 4, 0, 8, 2, nil},
+			},
+			want: lines(
+				"┌───┬───┐",
+				"│   │   │",
+				"└───┴───┘",
+			),
+		},
+		{
+			name: "stacked", width: 5, height: 5,
+			views: []viewSpec{
+				{"a", 0, 0, 4, 2, nil},
+				{"b", 0, 2, 4, 4, nil},
+			},
+			want: lines(
+				"┌───┐",
+				"│   │",
+				"├───┤",
+				"│   │",
+				"└───┘",
+			),
+		},
+		{
+			name: "four corners", width: 9, height: 5,
+			views: []viewSpec{
+				{"a", 0, 0, 4, 2, nil},
+				{"b", 4, 0, 8, 2, nil},
+				{"c", 0, 2, 4, 4, nil},
+				{"d", 4, 2, 8, 4, nil},
+			},
+			want: lines(
+				"┌───┬───┐",
+				"│   │   │",
+				"├───┼───┤",
+				"│   │   │",
+				"└───┴───┘",
+			),
+		},
+	}
+	for _, tt := range tests {
+		h, err := NewHarness(tt.width, tt.height, testLayout(tt.views...))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := h.Screen.String(); got != tt.want {
+			t.Errorf("%s: got\n%swant\n%s", tt.name, got, tt.want)
+		}
+		h.Close()
+	}
+}
+
+func TestHarnessEditor(t *testing.T) {
+	type key struct {
+		key Key
+		ch  rune
+	}
+	tests := []struct {
+		name   string
+		init   string
+		x      int // initial cursor position
+		keys   []key
+		want   string
+		cx, cy int
+	}{
+		{
+			name: "write", keys: []key{{0, 'a'}, {KeySpace, 0}, {0, 'b'}},
+			want: "│a b   │", cx: 4, cy: 1,
+		},
+		{
+			name: "backspace", init: "abc", x: 3,
+			keys: []key{{KeyBackspace2, 0}, {KeyBackspace, 0}},
+			want: "│a     │", cx: 2, cy: 1,
+		},
+		{
+			name: "delete", init: "abc",
+			keys: []key{{KeyDelete, 0}, {KeyDelete, 0}},
+			want: "│c     │", cx: 1, cy: 1,
+		},
+		{
+			name: "overwrite", init: "abc",
+			keys: []key{{KeyInsert, 0}, {0, 'x'}, {0, 'y'}},
+			want: "│xyc   │", cx: 3, cy: 1,
+		},
+	}
+	for _, tt := range tests {
+		var v *View
+		h, err := NewHarness(8, 3, testLayout(viewSpec{"a", 0, 0, 7, 2, func(nv *View) {
+			v = nv
+			v.Editable = true
+			fmt.Fprint(v, tt.init)
+		}}))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		h.Gui.Cursor = true
+		v.AbsMoveCursor(tt.x, 0, false)
+		for _, k := range tt.keys {
+			h.Screen.InjectKey(k.key, k.ch, ModNone)
+		}
+		if err := h.Run(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := strings.Split(h.Screen.String(), "\n")[1]; got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+		if x, y, _ := h.Screen.CursorPosition(); x != tt.cx || y != tt.cy {
+			t.Errorf("%s: cursor at %d,%d, want %d,%d", tt.name, x, y, tt.cx, tt.cy)
+		}
+		h.Close()
+	}
+}
+
+func TestHarnessExecute(t *testing.T) {
+	h, err := NewHarness(8, 3, testLayout(viewSpec{"a", 0, 0, 7, 2, nil}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.Gui.AddMode("normal", nil, nil)
+	if err := h.Gui.SetCurrentMode("normal"); err != nil {
+		t.Fatal(err)
+	}
+	err = h.Gui.SetKeybinding("normal", "", 'x', ModNone, func(g *Gui, v *View) error {
+		g.Execute(func(g *Gui) error {
+			v, err := g.View("a")
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(v, "done")
+			return nil
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Screen.InjectKey(0, 'x', ModNone)
+	if ok, err := h.Step(); !ok || err != nil {
+		t.Fatalf("Step() = %v, %v", ok, err)
+	}
+	want := lines(
+		"┌──────┐",
+		"│done  │",
+		"└──────┘",
+	)
+	if got := h.Screen.String(); got != want {
+		t.Errorf("got\n%swant\n%s", got, want)
+	}
+	if ok, err := h.Step(); ok || err != nil {
+		t.Errorf("Step() = %v, %v, want no pending event", ok, err)
+	}
+}
+
+// bindMode adds the mode "normal" to the Gui of h and makes it current.
+func bindMode(t *testing.T, h *Harness) {
+	h.Gui.AddMode("normal", nil, nil)
+	if err := h.Gui.SetCurrentMode("normal"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHarnessKeys(t *testing.T) {
+	var v *View
+	h, err := NewHarness(10, 3, testLayout(viewSpec{"a", 0, 0, 9, 2, func(nv *View) {
+		v = nv
+		v.Editable = true
+	}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.Gui.Cursor = true
+	bindMode(t, h)
+	calls := 0
+	err = h.Gui.SetKeybinding("normal", "", KeyCtrlA, ModNone, func(g *Gui, v *View) error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.Screen.InjectKey(KeyCtrlA, 0, ModNone)
+	h.Screen.InjectString("世 a")
+	// Step handles one event at a time
+	if ok, err := h.Step(); !ok || err != nil {
+		t.Fatalf("Step() = %v, %v", ok, err)
+	}
+	if calls != 1 {
+		t.Errorf("%d calls after the first step, want 1", calls)
+	}
+	if got := v.Buffer(); got != "" {
+		t.Errorf("buffer %q after the first step, want nothing typed", got)
+	}
+	if err := h.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Split(h.Screen.String(), "\n")[1], "│世 a    │"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	// the wide rune uses two cells
+	if x, y, _ := h.Screen.CursorPosition(); x != 5 || y != 1 {
+		t.Errorf("cursor at %d,%d, want 5,1", x, y)
+	}
+	if ok, err := h.Step(); ok || err != nil {
+		t.Errorf("Step() = %v, %v, want no pending event", ok, err)
+	}
+}
+
+func TestHarnessMouse(t *testing.T) {
+	h, err := NewHarness(14, 3, testLayout(
+		viewSpec{"a", 0, 0, 6, 2, func(v *View) { fmt.Fprint(v, "a世b") }},
+		viewSpec{"b", 7, 0, 13, 2, func(v *View) { fmt.Fprint(v, "xyz") }},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	bindMode(t, h)
+	var clicked []string
+	err = h.Gui.SetKeybinding("normal", "b", MouseLeft, ModNone, func(g *Gui, v *View) error {
+		clicked = append(clicked, v.Name())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		x, y   int // position of the click on the screen
+		view   string
+		cx, cy int
+	}{
+		{1, 1, "a", 0, 0},
+		// a click on the second cell of a wide rune moves the cursor to
+		// its first cell
+		{3, 1, "a", 1, 0},
+		{4, 1, "a", 3, 0},
+		{10, 1, "b", 2, 0},
+	}
+	for _, tt := range tests {
+		h.Screen.InjectMouse(tt.x, tt.y, MouseLeft, ModNone)
+		if err := h.Run(); err != nil {
+			t.Fatal(err)
+		}
+		v, err := h.Gui.View(tt.view)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cx, cy := v.Cursor(); cx != tt.cx || cy != tt.cy {
+			t.Errorf("click at %d,%d: cursor (%d, %d), want (%d, %d)", tt.x, tt.y, cx, cy, tt.cx, tt.cy)
+		}
+	}
+	if want := []string{"b"}; !reflect.DeepEqual(clicked, want) {
+		t.Errorf("clicked %q, want %q", clicked, want)
+	}
+}
+
+func TestHarnessStepQueue(t *testing.T) {
+	var v *View
+	h, err := NewHarness(8, 3, testLayout(viewSpec{"a", 0, 0, 7, 2, func(nv *View) { v = nv }}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	// the handlers queued by a handler are executed by the same step
+	h.Gui.Execute(func(g *Gui) error {
+		fmt.Fprint(v, "a")
+		g.Execute(func(g *Gui) error {
+			fmt.Fprint(v, "b")
+			return nil
+		})
+		return nil
+	})
+	h.Gui.Execute(func(g *Gui) error {
+		fmt.Fprint(v, "c")
+		return nil
+	})
+	if ok, err := h.Step(); !ok || err != nil {
+		t.Fatalf("Step() = %v, %v", ok, err)
+	}
+	if got, want := strings.Split(h.Screen.String(), "\n")[1], "│acb   │"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if ok, err := h.Step(); ok || err != nil {
+		t.Errorf("Step() = %v, %v, want an empty queue", ok, err)
+	}
+
+	// an error stops the step
+	errHandler := errors.New("handler")
+	h.Gui.Execute(func(g *Gui) error { return errHandler })
+	if err := h.Run(); err != errHandler {
+		t.Errorf("Run() error %v, want %v", err, errHandler)
+	}
+}
+
+func TestHarnessResize(t *testing.T) {
+	layout := func(g *Gui) error {
+		maxX, maxY := g.Size()
+		if _, err := g.SetView("a", "", 0, 0, maxX-1, maxY-1); err != nil && err != ErrUnknownView {
+			return err
+		}
+		return nil
+	}
+	h, err := NewHarness(6, 3, layout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	h.Screen.InjectResize(8, 4)
+	if err := h.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if x, y := h.Gui.Size(); x != 8 || y != 4 {
+		t.Errorf("size %dx%d, want 8x4", x, y)
+	}
+	want := lines(
+		"┌──────┐",
+		"│      │",
+		"│      │",
+		"└──────┘",
+	)
+	if got := h.Screen.String(); got != want {
+		t.Errorf("got\n%swant\n%s", got, want)
+	}
+}
@@ -45,9 +45,10 @@ const (
 	KeyArrowLeft      = Key(termbox.KeyArrowLeft)
 	KeyArrowRight     = Key(termbox.KeyArrowRight)
 
-	MouseLeft   = Key(termbox.MouseLeft)
-	MouseMiddle = Key(termbox.MouseMiddle)
-	MouseRight  = Key(termbox.MouseRight)
+	MouseLeft    = Key(termbox.MouseLeft)
+	MouseMiddle  = Key(termbox.MouseMiddle)
+	MouseRight   = Key(termbox.MouseRight)
+	MouseRelease = Key(termbox.MouseRelease)
 )
 
 // Keys combinations.
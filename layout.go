@@ -0,0 +1,383 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import "errors"
+
+// LayoutMode defines how a Container places its children.
+type LayoutMode int
+
+// Layout modes.
+const (
+	// LayoutNone keeps the coordinates given to SetView and SetViewNode.
+	LayoutNone LayoutMode = iota
+
+	// LayoutHorizontal places the children side by side, from left to
+	// right, each one using the whole height of the container.
+	LayoutHorizontal
+
+	// LayoutVertical stacks the children from top to bottom, each one
+	// using the whole width of the container.
+	LayoutVertical
+
+	// LayoutGrid places the children on the cells of a grid, see
+	// SetGridLayout.
+	LayoutGrid
+)
+
+// Constraint defines the size of a child of a Container along the
+// direction of its layout. The size of a child is the distance between its
+// first and its last edge, neighbours share the edge between them.
+//
+// A child is given Fixed cells if Fixed is set, Percent percent of the
+// container if Percent is set, and a share of the remaining space
+// proportional to Flex otherwise. Min and Max bound the size when they
+// are not 0.
+type Constraint struct {
+	Fixed   int
+	Percent int
+	Flex    int
+	Min     int
+	Max     int
+}
+
+// Fixed returns a Constraint of n cells.
+func Fixed(n int) Constraint {
+	return Constraint{Fixed: n}
+}
+
+// Percent returns a Constraint of p percent of the container.
+func Percent(p int) Constraint {
+	return Constraint{Percent: p}
+}
+
+// Flex returns a Constraint sharing the remaining space of the container
+// with the given weight.
+func Flex(weight int) Constraint {
+	return Constraint{Flex: weight}
+}
+
+// weight returns the weight of a flexible constraint.
+func (c Constraint) weight() int {
+	if c.Flex > 0 {
+		return c.Flex
+	}
+	return 1
+}
+
+// clamp bounds size with the Min and Max of the constraint. A size is
+// never smaller than 1.
+func (c Constraint) clamp(size int) int {
+	if c.Max > 0 && size > c.Max {
+		size = c.Max
+	}
+	if c.Min > 0 && size < c.Min {
+		size = c.Min
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// layoutItem associates a child of a Container with its constraint. The
+// order of the items is the order of the children in the layout, which
+// does not depend on the order used to draw them.
+type layoutItem struct {
+	name string
+	Constraint
+	cell  GridCell // position of the child in a LayoutGrid
+	ratio int      // size set by dragging an edge, in ratioScale units of the container
+}
+
+// SetViewNodeLayout sets how the viewNode with the given name places its
+// children. The root viewNode, named "", covers the whole screen.
+func (g *Gui) SetViewNodeLayout(name string, mode LayoutMode) error {
+	c, err := g.ViewNode(name)
+	if err != nil {
+		return err
+	}
+	c.layout = mode
+	g.arrange()
+	return nil
+}
+
+// SetLayoutView creates a new view inside the viewNode father, whose
+// coordinates are computed by the layout of father. If a view with the
+// same name already exists, its constraint is updated; otherwise, the
+// error ErrUnknownView is returned, which allows to assert if the View
+// must be initialized.
+func (g *Gui) SetLayoutView(name string, father string, cons Constraint) (*View, error) {
+	return g.setLayoutView(name, father, func(it *layoutItem) {
+		it.Constraint = cons
+	})
+}
+
+// SetLayoutViewNode creates a new viewNode inside the viewNode father,
+// whose coordinates are computed by the layout of father. mode defines how
+// the new viewNode places its own children. If a viewNode with the same
+// name already exists, its constraint and mode are updated; otherwise, the
+// error ErrUnknownViewNode is returned.
+func (g *Gui) SetLayoutViewNode(name string, father string, mode LayoutMode, cons Constraint) error {
+	return g.setLayoutViewNode(name, father, mode, func(it *layoutItem) {
+		it.Constraint = cons
+	})
+}
+
+// setLayoutView creates or updates a view placed by the layout of father.
+// set updates the layout item of the view.
+func (g *Gui) setLayoutView(name string, father string, set func(*layoutItem)) (*View, error) {
+	if name == "" {
+		return nil, errors.New("invalid name")
+	}
+	c, err := g.ViewNode(father)
+	if err != nil {
+		return nil, err
+	}
+
+	if v, err := g.View(name); err == nil {
+		set(c.item(name))
+		g.arrange()
+		return v, nil
+	}
+
+	v, err := g.SetView(name, father, 0, 0, 1, 1)
+	if err != ErrUnknownView {
+		return v, err
+	}
+	set(c.item(name))
+	g.arrange()
+	return v, ErrUnknownView
+}
+
+// setLayoutViewNode creates or updates a viewNode placed by the layout of
+// father. set updates the layout item of the viewNode.
+func (g *Gui) setLayoutViewNode(name string, father string, mode LayoutMode, set func(*layoutItem)) error {
+	if name == "" {
+		return errors.New("invalid name")
+	}
+	c, err := g.ViewNode(father)
+	if err != nil {
+		return err
+	}
+
+	if vn, err := g.ViewNode(name); err == nil {
+		vn.layout = mode
+		set(c.item(name))
+		g.arrange()
+		return nil
+	}
+
+	if err := g.SetViewNode(name, father, 0, 0, 1, 1); err != ErrUnknownViewNode {
+		return err
+	}
+	vn, _ := g.ViewNode(name)
+	vn.layout = mode
+	set(c.item(name))
+	g.arrange()
+	return ErrUnknownViewNode
+}
+
+// item returns the layout item of the child with the given name, appending
+// it at the end of the layout if it is not part of it yet.
+func (c *Container) item(name string) *layoutItem {
+	for i := range c.items {
+		if c.items[i].name == name {
+			return &c.items[i]
+		}
+	}
+	c.items = append(c.items, layoutItem{name: name})
+	return &c.items[len(c.items)-1]
+}
+
+// removeItem removes the child with the given name from the layout.
+func (c *Container) removeItem(name string) {
+	for i := range c.items {
+		if c.items[i].name == name {
+			c.items = append(c.items[:i], c.items[i+1:]...)
+			return
+		}
+	}
+}
+
+// child returns the direct child with the given name.
+func (c *Container) child(name string) geom {
+	for _, node := range c.childrens {
+		if node.Name() == name {
+			return node
+		}
+	}
+	return nil
+}
+
+// arrange computes the coordinates of the laid out views, starting from
+// the root viewNode which covers the whole screen.
+func (g *Gui) arrange() {
+	g.viewTree.x0, g.viewTree.y0 = 0, 0
+	g.viewTree.x1, g.viewTree.y1 = g.maxX-1, g.maxY-1
+	g.viewTree.arrange()
+}
+
+// arrange computes the coordinates of the children of c, then of their
+// own children.
+func (c *Container) arrange() {
+	switch c.layout {
+	case LayoutHorizontal, LayoutVertical:
+		c.arrangeLinear()
+	case LayoutGrid:
+		c.arrangeGrid()
+	}
+	for _, node := range c.childrens {
+		if cont, ok := node.(*Container); ok {
+			cont.arrange()
+		}
+	}
+}
+
+// arrangeLinear places the children of c in a row or in a column.
+func (c *Container) arrangeLinear() {
+	nodes, items := c.linearItems()
+	start, end := c.mainAxis()
+	total := end - start
+
+	cons := make([]Constraint, len(items))
+	resized := 0
+	for i, it := range items {
+		cons[i] = it.Constraint
+		if it.ratio > 0 {
+			cons[i] = Constraint{Fixed: (it.ratio*total + ratioScale/2) / ratioScale, Min: it.Min, Max: it.Max}
+			resized++
+		}
+	}
+	sizes := distribute(total, cons)
+	if resized > 0 && resized == len(sizes) {
+		// every child has been resized by the user, the last one takes
+		// the rounding leftovers
+		used := 0
+		for _, size := range sizes {
+			used += size
+		}
+		sizes[len(sizes)-1] += total - used
+	}
+
+	pos := start
+	for i, node := range nodes {
+		if c.layout == LayoutHorizontal {
+			setPosition(node, pos, c.y0, pos+sizes[i], c.y1)
+		} else {
+			setPosition(node, c.x0, pos, c.x1, pos+sizes[i])
+		}
+		pos += sizes[i]
+	}
+}
+
+// linearItems returns the children of c placed by a linear layout, in
+// order, with their layout items. Hidden views are skipped.
+func (c *Container) linearItems() ([]geom, []*layoutItem) {
+	var (
+		nodes []geom
+		items []*layoutItem
+	)
+	for i := range c.items {
+		node := c.child(c.items[i].name)
+		if node == nil {
+			continue
+		}
+		if v, ok := node.(*View); ok && v.Hidden {
+			continue
+		}
+		nodes = append(nodes, node)
+		items = append(items, &c.items[i])
+	}
+	return nodes, items
+}
+
+// mainAxis returns the first and the last edge of c along the direction
+// of its linear layout.
+func (c *Container) mainAxis() (start, end int) {
+	if c.layout == LayoutHorizontal {
+		return c.x0, c.x1
+	}
+	return c.y0, c.y1
+}
+
+// setPosition moves a view or a viewNode. Views are tainted if their
+// coordinates change.
+func setPosition(node geom, x0, y0, x1, y1 int) {
+	switch n := node.(type) {
+	case *View:
+		if n.x0 != x0 || n.y0 != y0 || n.x1 != x1 || n.y1 != y1 {
+			n.x0, n.y0, n.x1, n.y1 = x0, y0, x1, y1
+			n.tainted = true
+		}
+	case *Container:
+		n.x0, n.y0, n.x1, n.y1 = x0, y0, x1, y1
+	}
+}
+
+// distribute splits total cells between the given constraints. Fixed and
+// percentage sizes are resolved first, then the remaining space is shared
+// between the flexible constraints. A flexible constraint reaching one of
+// its bounds is fixed to it and the space is shared again between the
+// others.
+func distribute(total int, cons []Constraint) []int {
+	sizes := make([]int, len(cons))
+	flexible := make([]bool, len(cons))
+
+	remaining := total
+	for i, c := range cons {
+		switch {
+		case c.Fixed > 0:
+			sizes[i] = c.clamp(c.Fixed)
+		case c.Percent > 0:
+			sizes[i] = c.clamp(total * c.Percent / 100)
+		default:
+			flexible[i] = true
+			continue
+		}
+		remaining -= sizes[i]
+	}
+
+	for {
+		weight := 0
+		for i, c := range cons {
+			if flexible[i] {
+				weight += c.weight()
+			}
+		}
+		if weight == 0 {
+			return sizes
+		}
+
+		bounded := false
+		for i, c := range cons {
+			if !flexible[i] {
+				continue
+			}
+			size := remaining * c.weight() / weight
+			if s := c.clamp(size); s != size {
+				sizes[i] = s
+				flexible[i] = false
+				remaining -= s
+				bounded = true
+			}
+		}
+		if bounded {
+			continue
+		}
+
+		// the last flexible child takes the rounding leftovers
+		used, last := 0, 0
+		for i, c := range cons {
+			if flexible[i] {
+				sizes[i] = remaining * c.weight() / weight
+				used += sizes[i]
+				last = i
+			}
+		}
+		sizes[last] += remaining - used
+		return sizes
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDistribute(t *testing.T) {
+	tests := []struct {
+		name  string
+		total int
+		cons  []Constraint
+		want  []int
+	}{
+		{"none", 10, nil, []int{}},
+		{"fixed percent flex", 100, []Constraint{Fixed(10), Percent(20), Flex(1)}, []int{10, 20, 70}},
+		{"weights", 100, []Constraint{Fixed(10), Flex(1), Flex(3)}, []int{10, 22, 68}},
+		{"rounding", 10, []Constraint{Flex(1), Flex(1), Flex(1)}, []int{3, 3, 4}},
+		{"flex max", 100, []Constraint{{Flex: 1, Max: 10}, Flex(1)}, []int{10, 90}},
+		{"flex min", 20, []Constraint{{Flex: 1, Min: 15}, Flex(1), Flex(1)}, []int{15, 2, 3}},
+		{"percent max", 100, []Constraint{{Percent: 50, Max: 30}, Flex(1)}, []int{30, 70}},
+		{"fixed min", 100, []Constraint{{Fixed: 5, Min: 8}, Flex(1)}, []int{8, 92}},
+		{"overflow", 10, []Constraint{Fixed(8), Fixed(6), Flex(1)}, []int{8, 6, 1}},
+		{"overflow percent", 5, []Constraint{Fixed(8), Percent(50)}, []int{8, 2}},
+		{"empty", 0, []Constraint{Flex(1), Flex(2)}, []int{1, 1}},
+	}
+	for _, tt := range tests {
+		if got := distribute(tt.total, tt.cons); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+// Screen is the backend a Gui draws on and reads its events from. The
+// default implementation is a real terminal driven by termbox, other
+// implementations can be given to Gui.Init with WithScreen.
+type Screen interface {
+	// Init prepares the screen. It is called once by Gui.Init.
+	Init() error
+
+	// Close releases the screen. It is called by Gui.Close.
+	Close()
+
+	// Size returns the number of columns and rows of the screen.
+	Size() (x, y int)
+
+	// SetCell sets the rune and the colors of the cell at (x, y). It is
+	// a no-op if the position is out of the screen.
+	SetCell(x, y int, ch rune, fg, bg Attribute)
+
+	// Cell returns the rune and the colors of the cell at (x, y), as set
+	// since the last call to Clear.
+	Cell(x, y int) (ch rune, fg, bg Attribute)
+
+	// Clear fills the whole screen with blank cells using the given
+	// colors.
+	Clear(fg, bg Attribute)
+
+	// SetCursor shows the cursor at (x, y).
+	SetCursor(x, y int)
+
+	// HideCursor hides the cursor.
+	HideCursor()
+
+	// SetMouse enables or disables the reporting of mouse events.
+	SetMouse(enabled bool)
+
+	// SetColorMode sets the colors the screen displays and returns the
+	// mode actually used, which can support less colors. Colors not
+	// supported are replaced by the closest supported ones.
+	SetColorMode(mode ColorMode) ColorMode
+
+	// WriteSequence sends seq, an escape sequence handled by the terminal
+	// itself like OSC 52, after the cells displayed by the next call to
+	// Flush. A screen which is not a terminal can record or ignore it.
+	WriteSequence(seq string)
+
+	// Flush displays the cells set since the last call to Flush.
+	Flush() error
+
+	// PollEvent blocks until an event is available and returns it.
+	PollEvent() Event
+}
+
+// EventType is the type of an Event.
+type EventType uint8
+
+// Event types.
+const (
+	EventKey EventType = iota
+	EventResize
+	EventMouse
+	EventError
+	EventNone
+)
+
+// Event represents an input event reported by a Screen.
+type Event struct {
+	Type   EventType
+	Key    Key      // one of the Key constants, 0 if Ch is set
+	Ch     rune     // the unicode character, 0 if Key is set
+	Mod    Modifier // one of the Modifier constants
+	Width  int      // width of the screen on EventResize
+	Height int      // height of the screen on EventResize
+	MouseX int      // x position of the mouse on EventMouse
+	MouseY int      // y position of the mouse on EventMouse
+	Err    error    // error on EventError
+}
+
+// Option configures a Gui when it is initialized.
+type Option func(*Gui)
+
+// WithScreen makes the Gui use s instead of the default termbox screen.
+func WithScreen(s Screen) Option {
+	return func(g *Gui) {
+		g.screen = s
+	}
+}
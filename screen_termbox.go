@@ -0,0 +1,164 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"io"
+	"os"
+
+	"github.com/nsf/termbox-go"
+)
+
+// termboxScreen is the default Screen, backed by termbox.
+type termboxScreen struct {
+	colorMode ColorMode
+	tty       io.Writer // terminal written by termbox
+	sequences []string  // written by the next flush
+}
+
+// newTermboxScreen returns a Screen drawing on the terminal.
+func newTermboxScreen() Screen {
+	return &termboxScreen{}
+}
+
+func (s *termboxScreen) Init() error {
+	if err := termbox.Init(); err != nil {
+		return err
+	}
+	s.tty = os.Stdout
+	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		s.tty = tty
+	}
+	return nil
+}
+
+func (s *termboxScreen) Close() {
+	termbox.Close()
+	if tty, ok := s.tty.(*os.File); ok && tty != os.Stdout {
+		tty.Close()
+	}
+}
+
+func (s *termboxScreen) Size() (x, y int) {
+	return termbox.Size()
+}
+
+func (s *termboxScreen) SetCell(x, y int, ch rune, fg, bg Attribute) {
+	termbox.SetCell(x, y, ch, s.attribute(fg), s.attribute(bg))
+}
+
+func (s *termboxScreen) Cell(x, y int) (ch rune, fg, bg Attribute) {
+	maxX, maxY := termbox.Size()
+	if x < 0 || y < 0 || x >= maxX || y >= maxY {
+		return ' ', ColorDefault, ColorDefault
+	}
+	c := termbox.CellBuffer()[y*maxX+x]
+	return c.Ch, Attribute(c.Fg), Attribute(c.Bg)
+}
+
+func (s *termboxScreen) Clear(fg, bg Attribute) {
+	termbox.Clear(s.attribute(fg), s.attribute(bg))
+}
+
+func (s *termboxScreen) SetCursor(x, y int) {
+	termbox.SetCursor(x, y)
+}
+
+func (s *termboxScreen) HideCursor() {
+	termbox.HideCursor()
+}
+
+func (s *termboxScreen) SetMouse(enabled bool) {
+	inputMode := termbox.InputEsc
+	if enabled {
+		inputMode |= termbox.InputMouse
+	}
+	termbox.SetInputMode(inputMode)
+}
+
+// SetColorMode sets the output mode of termbox matching mode.
+func (s *termboxScreen) SetColorMode(mode ColorMode) ColorMode {
+	switch mode {
+	case ColorMode8:
+		termbox.SetOutputMode(termbox.OutputNormal)
+	case ColorMode256:
+		termbox.SetOutputMode(termbox.Output256)
+	default:
+		termbox.SetOutputMode(termbox.OutputRGB)
+		mode = ColorModeTrueColor
+	}
+	s.colorMode = mode
+	return mode
+}
+
+// attribute converts a to a termbox attribute supported by the output
+// mode. In the 24-bit mode, the colors of the palette are converted to
+// their RGB values.
+func (s *termboxScreen) attribute(a Attribute) termbox.Attribute {
+	a = a.degrade(s.colorMode)
+	var ta termbox.Attribute
+	if a&AttrBold != 0 {
+		ta |= termbox.AttrBold
+	}
+	if a&AttrUnderline != 0 {
+		ta |= termbox.AttrUnderline
+	}
+	if a&AttrReverse != 0 {
+		ta |= termbox.AttrReverse
+	}
+	if r, g, b, ok := a.rgb(); ok {
+		return ta | termbox.RGBToAttribute(r, g, b)
+	}
+	idx := a & paletteMask
+	if s.colorMode == ColorModeTrueColor && idx != 0 {
+		r, g, b := paletteRGB(uint8(idx - 1))
+		return ta | termbox.RGBToAttribute(r, g, b)
+	}
+	return ta | termbox.Attribute(idx)
+}
+
+func (s *termboxScreen) WriteSequence(seq string) {
+	s.sequences = append(s.sequences, seq)
+}
+
+// Flush flushes termbox, then writes the pending sequences to the
+// terminal, so that they are not mixed with the output of termbox.
+func (s *termboxScreen) Flush() error {
+	err := termbox.Flush()
+	for _, seq := range s.sequences {
+		if s.tty != nil {
+			io.WriteString(s.tty, seq)
+		}
+	}
+	s.sequences = nil
+	return err
+}
+
+func (s *termboxScreen) PollEvent() Event {
+	ev := termbox.PollEvent()
+	e := Event{
+		Key:    Key(ev.Key),
+		Ch:     ev.Ch,
+		Mod:    Modifier(ev.Mod),
+		Width:  ev.Width,
+		Height: ev.Height,
+		MouseX: ev.MouseX,
+		MouseY: ev.MouseY,
+		Err:    ev.Err,
+	}
+	switch ev.Type {
+	case termbox.EventKey:
+		e.Type = EventKey
+	case termbox.EventResize:
+		e.Type = EventResize
+	case termbox.EventMouse:
+		e.Type = EventMouse
+	case termbox.EventError:
+		e.Type = EventError
+	default:
+		e.Type = EventNone
+	}
+	return e
+}
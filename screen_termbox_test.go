@@ -0,0 +1,60 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"testing"
+
+	"github.com/nsf/termbox-go"
+)
+
+func TestTermboxColorMode(t *testing.T) {
+	tests := []struct {
+		mode   ColorMode
+		want   ColorMode
+		output termbox.OutputMode
+	}{
+		{ColorMode8, ColorMode8, termbox.OutputNormal},
+		{ColorMode256, ColorMode256, termbox.Output256},
+		{ColorModeTrueColor, ColorModeTrueColor, termbox.OutputRGB},
+		{ColorModeAuto, ColorModeTrueColor, termbox.OutputRGB},
+	}
+	defer termbox.SetOutputMode(termbox.OutputNormal)
+	for _, tt := range tests {
+		s := &termboxScreen{}
+		if got := s.SetColorMode(tt.mode); got != tt.want {
+			t.Errorf("SetColorMode(%v) = %v, want %v", tt.mode, got, tt.want)
+		}
+		if got := termbox.SetOutputMode(termbox.OutputCurrent); got != tt.output {
+			t.Errorf("SetColorMode(%v): output mode %v, want %v", tt.mode, got, tt.output)
+		}
+	}
+}
+
+func TestTermboxAttribute(t *testing.T) {
+	tests := []struct {
+		mode ColorMode
+		a    Attribute
+		want termbox.Attribute
+	}{
+		{ColorMode8, ColorDefault, 0},
+		{ColorMode8, ColorRed | AttrBold, termbox.Attribute(ColorRed) | termbox.AttrBold},
+		{ColorMode8, ColorRGB(250, 10, 10) | AttrUnderline, termbox.Attribute(ColorRed) | termbox.AttrUnderline},
+		{ColorMode256, Color256(208) | AttrReverse, 209 | termbox.AttrReverse},
+		{ColorMode256, ColorRGB(255, 135, 0), 209},
+		{ColorModeTrueColor, ColorDefault | AttrBold, termbox.AttrBold},
+		{ColorModeTrueColor, ColorRGB(1, 2, 3) | AttrBold, termbox.RGBToAttribute(1, 2, 3) | termbox.AttrBold},
+		{ColorModeTrueColor, ColorRed, termbox.RGBToAttribute(205, 0, 0)},
+		{ColorModeTrueColor, Color256(208), termbox.RGBToAttribute(255, 135, 0)},
+	}
+	defer termbox.SetOutputMode(termbox.OutputNormal)
+	for _, tt := range tests {
+		s := &termboxScreen{}
+		s.SetColorMode(tt.mode)
+		if got := s.attribute(tt.a); got != tt.want {
+			t.Errorf("mode %v: attribute(%v) = %#x, want %#x", tt.mode, tt.a, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package gocui
 
-import "strings"
+import (
+	"strings"
+	"unicode/utf8"
+)
 
 func (v *View) SearchForward(pattern string) (bool, int, int) {
 	rx, ry, _ := v.realPosition(v.cx, v.cy)
@@ -13,15 +16,44 @@ func (v *View) SearchForward(pattern string) (bool, int, int) {
 		// Start searching one character beyond where we are
 		// or we won't be able to continue to the next match
 		if len(v.lines[ry]) > rx+1 {
-			if ind := strings.Index(string(v.lines[ry][rx+1:]), pattern); ind > -1 {
-				return true, ind + rx, ry
+			s := cellsString(v.lines[ry][rx+1:])
+			if ind := strings.Index(s, pattern); ind > -1 {
+				return true, utf8.RuneCountInString(s[:ind]) + rx, ry
 			}
 		}
 		for i := ry + 1; i < len(v.lines); i++ {
-			if ind := strings.Index(string(v.lines[i]), pattern); ind > -1 {
-				return true, ind, i
+			s := cellsString(v.lines[i])
+			if ind := strings.Index(s, pattern); ind > -1 {
+				return true, utf8.RuneCountInString(s[:ind]), i
 			}
 		}
 	}
 	return false, 0, 0
 }
+
+// searchMatches returns, for each cell of the line y of the internal
+// buffer, whether it is part of a match of the last search. It returns nil
+// if there is no match.
+func (v *View) searchMatches(y int) []bool {
+	if v.searchString == "" || y < 0 || y >= len(v.lines) {
+		return nil
+	}
+	var matches []bool
+	s := cellsString(v.lines[y])
+	n := utf8.RuneCountInString(v.searchString)
+	for i, x := 0, 0; ; {
+		ind := strings.Index(s[i:], v.searchString)
+		if ind < 0 {
+			return matches
+		}
+		if matches == nil {
+			matches = make([]bool, len(v.lines[y]))
+		}
+		x += utf8.RuneCountInString(s[i : i+ind])
+		for k := x; k < x+n; k++ {
+			matches[k] = true
+		}
+		i += ind + len(v.searchString)
+		x += n
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import "strings"
+
+// SelectionMode defines the shape of the selection of a view.
+type SelectionMode int
+
+// Selection modes.
+const (
+	// SelectNone means that nothing is selected.
+	SelectNone SelectionMode = iota
+
+	// SelectChar selects the characters from the anchor to the cursor,
+	// including the line breaks between them.
+	SelectChar
+
+	// SelectLine selects the whole lines from the line of the anchor to
+	// the line of the cursor.
+	SelectLine
+
+	// SelectBlock selects the rectangle between the columns and the lines
+	// of the anchor and of the cursor.
+	SelectBlock
+)
+
+// A selection goes from its anchor to the cursor. The anchor is a position
+// of the internal buffer, while the cursor is a position of the view. The
+// end of a selection is excluded, like the character under the cursor.
+
+// StartSelection selects the text from the current position of the cursor
+// to the next positions of the cursor, with the given mode. The selected
+// text is displayed with SelFgColor and SelBgColor.
+func (v *View) StartSelection(mode SelectionMode) {
+	v.selMode = mode
+	v.selX, v.selY, _ = v.realPosition(v.cx, v.cy)
+}
+
+// SetSelectionMode changes the mode of the current selection, keeping its
+// anchor. It starts a new selection if there is none.
+func (v *View) SetSelectionMode(mode SelectionMode) {
+	if v.selMode == SelectNone {
+		v.StartSelection(mode)
+		return
+	}
+	v.selMode = mode
+}
+
+// SelectionMode returns the mode of the current selection, SelectNone if
+// nothing is selected.
+func (v *View) SelectionMode() SelectionMode {
+	return v.selMode
+}
+
+// ClearSelection deselects the text.
+func (v *View) ClearSelection() {
+	v.selMode = SelectNone
+}
+
+// selectionRange returns the anchor and the cursor of the selection in the
+// internal buffer, the first position being before the second one. ok is
+// false if nothing is selected.
+func (v *View) selectionRange() (x0, y0, x1, y1 int, ok bool) {
+	if v.selMode == SelectNone || len(v.lines) == 0 {
+		return 0, 0, 0, 0, false
+	}
+	x0, y0 = v.clampPosition(v.selX, v.selY)
+	cx, cy, err := v.realPosition(v.cx, v.cy)
+	if err != nil {
+		return 0, 0, 0, 0, false
+	}
+	x1, y1 = v.clampPosition(cx, cy)
+	if y1 < y0 || y1 == y0 && x1 < x0 {
+		x0, y0, x1, y1 = x1, y1, x0, y0
+	}
+	return x0, y0, x1, y1, true
+}
+
+// clampPosition returns the closest position of the internal buffer to the
+// position (x, y).
+func (v *View) clampPosition(x, y int) (int, int) {
+	if y >= len(v.lines) {
+		y = len(v.lines) - 1
+	}
+	if y < 0 {
+		y = 0
+	}
+	if x > len(v.lines[y]) {
+		x = len(v.lines[y])
+	}
+	if x < 0 {
+		x = 0
+	}
+	return x, y
+}
+
+// blockColumns returns the first column and the column following the last
+// one of a block selection.
+func (v *View) blockColumns() (c0, c1 int) {
+	tw := v.tabWidth()
+	ax, ay := v.clampPosition(v.selX, v.selY)
+	c0 = indexColumn(v.lines[ay], ax, tw)
+	cx, cy, _ := v.realPosition(v.cx, v.cy)
+	cx, cy = v.clampPosition(cx, cy)
+	c1 = indexColumn(v.lines[cy], cx, tw)
+	if c1 < c0 {
+		c0, c1 = c1, c0
+	}
+	return c0, c1
+}
+
+// selectedSpan returns the indexes of the first cell and of the cell
+// following the last one of the selection on the line y of the internal
+// buffer. x0, y0, x1 and y1 are the bounds returned by selectionRange.
+func (v *View) selectedSpan(y, x0, y0, x1, y1 int) (i0, i1 int) {
+	line := v.lines[y]
+	switch v.selMode {
+	case SelectChar:
+		i0, i1 = 0, len(line)
+		if y == y0 {
+			i0 = x0
+		}
+		if y == y1 {
+			i1 = x1
+		}
+	case SelectLine:
+		i0, i1 = 0, len(line)
+	case SelectBlock:
+		tw := v.tabWidth()
+		c0, c1 := v.blockColumns()
+		i0, i1 = columnIndex(line, c0, tw), columnIndex(line, c1, tw)
+	}
+	if i0 > len(line) {
+		i0 = len(line)
+	}
+	if i1 > len(line) {
+		i1 = len(line)
+	}
+	return i0, i1
+}
+
+// selected reports whether the cell (x, y) of the internal buffer is
+// selected.
+func (v *View) selected(x, y int) bool {
+	x0, y0, x1, y1, ok := v.selectionRange()
+	if !ok || y < y0 || y > y1 || y >= len(v.lines) {
+		return false
+	}
+	i0, i1 := v.selectedSpan(y, x0, y0, x1, y1)
+	return x >= i0 && x < i1
+}
+
+// SelectedText returns the selected text. The lines of a line selection
+// end with a line break, the lines of a block selection are separated by
+// line breaks.
+func (v *View) SelectedText() string {
+	x0, y0, x1, y1, ok := v.selectionRange()
+	if !ok {
+		return ""
+	}
+	var parts []string
+	for y := y0; y <= y1; y++ {
+		i0, i1 := v.selectedSpan(y, x0, y0, x1, y1)
+		parts = append(parts, cellsString(v.lines[y][i0:i1]))
+	}
+	s := strings.Join(parts, "\n")
+	if v.selMode == SelectLine {
+		s += "\n"
+	}
+	return strings.Replace(s, "\x00", " ", -1)
+}
+
+// DeleteSelection deletes the selected text, see ReplaceSelection.
+func (v *View) DeleteSelection() {
+	v.ReplaceSelection("")
+}
+
+// ReplaceSelection replaces the selected text with s and clears the
+// selection, as a single command of View.Actions. The lines of s replace
+// the lines of a line selection. Each line of a block selection is
+// replaced by the line of s with the same index, or by s if it is a single
+// line, so that it can be inserted on every line of the block.
+func (v *View) ReplaceSelection(s string) {
+	x0, y0, x1, y1, ok := v.selectionRange()
+	if !ok {
+		return
+	}
+	var (
+		text   [][]cell
+		ax, ay int // position of the cursor after the replacement
+	)
+	switch v.selMode {
+	case SelectChar:
+		text = stringText(s)
+		ax, ay = textEnd(x0, y0, text)
+	case SelectLine:
+		x0, x1 = 0, len(v.lines[y1])
+		if s != "" {
+			text = stringText(strings.TrimSuffix(s, "\n"))
+			ax, ay = 0, y0
+			break
+		}
+		// the lines are deleted with the line break before them, or after
+		// them for the first lines of the buffer
+		text = [][]cell{nil}
+		switch {
+		case y0 > 0:
+			x0, y0 = len(v.lines[y0-1]), y0-1
+			ay = y0
+			if y1+1 < len(v.lines) {
+				ay++
+			}
+		case y1+1 < len(v.lines):
+			x1, y1 = 0, y1+1
+		}
+	case SelectBlock:
+		tw := v.tabWidth()
+		c0, _ := v.blockColumns()
+		parts := strings.Split(s, "\n")
+		for y := y0; y <= y1; y++ {
+			i0, i1 := v.selectedSpan(y, x0, y0, x1, y1)
+			p := parts[0]
+			if len(parts) > 1 {
+				p = ""
+				if y-y0 < len(parts) {
+					p = parts[y-y0]
+				}
+			}
+			var line []cell
+			line = append(line, v.lines[y][:i0]...)
+			if p != "" {
+				// short lines are padded up to the block
+				for w := lineWidth(line, tw); w < c0; w++ {
+					line = append(line, cell{chr: ' '})
+				}
+			}
+			line = append(line, stringCells(p)...)
+			if y == y0 {
+				ax, ay = len(line), y0
+			}
+			text = append(text, append(line, v.lines[y][i1:]...))
+		}
+		x0, x1 = 0, len(v.lines[y1])
+	}
+	v.selMode = SelectNone
+	v.replaceRange(x0, y0, x1, y1, text, ax, ay)
+}
+
+// stringText returns the lines of s, as cells.
+func stringText(s string) [][]cell {
+	var text [][]cell
+	for _, l := range strings.Split(s, "\n") {
+		text = append(text, stringCells(l))
+	}
+	return text
+}
+
+// stringCells returns the cells of the runes of s.
+func stringCells(s string) []cell {
+	var cs []cell
+	for _, ch := range s {
+		cs = append(cs, cell{chr: ch})
+	}
+	return cs
+}
@@ -0,0 +1,70 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"fmt"
+	"testing"
+)
+
+// selText is the text of the views of the selection tests, its second line
+// is shorter than the other ones.
+const selText = "abcdef\nab\nabcdef\nxyz"
+
+func TestReplaceSelection(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           SelectionMode
+		x0, y0, x1, y1 int // anchor and cursor of the selection
+		s              string
+		buffer         string
+		cx, cy         int // cursor after the replacement
+	}{
+		{"char delete", SelectChar, 1, 0, 3, 0, "", "adef\nab\nabcdef\nxyz\n", 1, 0},
+		{"char lines", SelectChar, 2, 0, 1, 2, "X\nY", "abX\nYbcdef\nxyz\n", 1, 1},
+		{"char backward", SelectChar, 3, 2, 1, 0, "-", "a-def\nxyz\n", 2, 0},
+		{"line replace", SelectLine, 2, 1, 0, 2, "L1\nL2\n", "abcdef\nL1\nL2\nxyz\n", 0, 1},
+		{"line delete", SelectLine, 1, 1, 1, 2, "", "abcdef\nxyz\n", 0, 1},
+		{"line delete first", SelectLine, 0, 1, 0, 0, "", "abcdef\nxyz\n", 0, 0},
+		{"line delete last", SelectLine, 2, 3, 2, 3, "", "abcdef\nab\nabcdef\n", 0, 2},
+		{"block insert", SelectBlock, 1, 0, 3, 2, "#", "a#def\na#\na#def\nxyz\n", 2, 0},
+		{"block lines", SelectBlock, 1, 0, 2, 2, "1\n2", "a1cdef\na2\nacdef\nxyz\n", 2, 0},
+		// the short line is padded up to the block, unless the block is
+		// deleted
+		{"block past short line", SelectBlock, 3, 0, 5, 2, "#", "abc#f\nab #\nabc#f\nxyz\n", 4, 0},
+		{"block delete past short line", SelectBlock, 3, 0, 5, 2, "", "abcf\nab\nabcf\nxyz\n", 3, 0},
+	}
+	for _, tt := range tests {
+		var v *View
+		h, err := NewHarness(20, 8, testLayout(viewSpec{"a", 0, 0, 19, 7, func(nv *View) {
+			v = nv
+			v.Editable = true
+			fmt.Fprint(v, selText)
+		}}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		selectText(v, tt.mode, tt.x0, tt.y0, tt.x1, tt.y1)
+		v.ReplaceSelection(tt.s)
+		if got := v.Buffer(); got != tt.buffer {
+			t.Errorf("%s: buffer %q, want %q", tt.name, got, tt.buffer)
+		}
+		if got := v.SelectionMode(); got != SelectNone {
+			t.Errorf("%s: selection mode %v after the replacement", tt.name, got)
+		}
+		if err := h.Draw(); err != nil {
+			t.Fatal(err)
+		}
+		if cx, cy := v.Cursor(); cx != tt.cx || cy != tt.cy {
+			t.Errorf("%s: cursor (%d, %d), want (%d, %d)", tt.name, cx, cy, tt.cx, tt.cy)
+		}
+		// the replacement is a single command
+		v.Actions.Undo()
+		if got, want := v.Buffer(), selText+"\n"; got != want {
+			t.Errorf("%s: buffer %q after undo, want %q", tt.name, got, want)
+		}
+		h.Close()
+	}
+}
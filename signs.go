@@ -0,0 +1,243 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"errors"
+	"sort"
+)
+
+// signColumnWidth is the number of columns of the sign column. A sign
+// which is not a wide character is followed by a space.
+const signColumnWidth = 2
+
+// Sign is a marker attached to a line of the internal buffer of a view,
+// like a breakpoint, the status of the line in a diff or a lint error.
+// Signs are displayed in the sign column of the view, see View.SignColumn.
+// A zero Style uses the colors of the view.
+type Sign struct {
+	// Group identifies the kind of the sign. A line has at most one sign
+	// of each group.
+	Group string
+
+	Rune    rune
+	Style   Style
+	Tooltip string
+}
+
+// SetSign attaches a sign to the line y of the internal buffer, replacing
+// the sign of the same group. If a line has several signs, the last one
+// set is displayed.
+func (v *View) SetSign(y int, s Sign) error {
+	if y < 0 || y >= len(v.lines) {
+		return errors.New("invalid line")
+	}
+	if v.signs == nil {
+		v.signs = make(map[int][]Sign)
+	}
+	v.signs[y] = append(removeSignGroup(v.signs[y], s.Group), s)
+	return nil
+}
+
+// RemoveSign removes the sign of the given group from the line y of the
+// internal buffer.
+func (v *View) RemoveSign(y int, group string) {
+	if ss := removeSignGroup(v.signs[y], group); len(ss) > 0 {
+		v.signs[y] = ss
+	} else {
+		delete(v.signs, y)
+	}
+}
+
+// ClearSigns removes the signs of the given group from all the lines, or
+// all the signs if group is empty.
+func (v *View) ClearSigns(group string) {
+	if group == "" {
+		v.signs = nil
+		return
+	}
+	for y := range v.signs {
+		v.RemoveSign(y, group)
+	}
+}
+
+// Signs returns the signs attached to the line y of the internal buffer,
+// in the order they have been set.
+func (v *View) Signs(y int) []Sign {
+	ss := make([]Sign, len(v.signs[y]))
+	copy(ss, v.signs[y])
+	return ss
+}
+
+// SignAt returns the sign displayed at the point (x, y) of the view. x is
+// relative to the first column of the view, where the sign column is, and
+// y to its first row. It allows to display the tooltip of a sign under the
+// mouse.
+func (v *View) SignAt(x, y int) (Sign, bool) {
+	if !v.SignColumn || x < 0 || x >= signColumnWidth {
+		return Sign{}, false
+	}
+	vy := v.oy + y
+	if y < 0 || vy >= len(v.viewLines) || v.viewLines[vy].linesX > 0 {
+		return Sign{}, false
+	}
+	ss := v.signs[v.viewLines[vy].linesY]
+	if len(ss) == 0 {
+		return Sign{}, false
+	}
+	return ss[len(ss)-1], true
+}
+
+// removeSignGroup returns ss without the sign of the given group.
+func removeSignGroup(ss []Sign, group string) []Sign {
+	for i, s := range ss {
+		if s.Group == group {
+			return append(ss[:i:i], ss[i+1:]...)
+		}
+	}
+	return ss
+}
+
+// signWidth returns the number of columns of the sign column, 0 if it is
+// not displayed.
+func (v *View) signWidth() int {
+	if !v.SignColumn {
+		return 0
+	}
+	return signColumnWidth
+}
+
+// drawSign draws the sign of the line y of the internal buffer in the sign
+// column of the row vy of the view.
+func (v *View) drawSign(vy, y int) {
+	if v.x1-v.x0-1 < signColumnWidth {
+		return
+	}
+	ss := v.signs[y]
+	if len(ss) == 0 {
+		return
+	}
+	s := ss[len(ss)-1]
+	fg, bg := v.FgColor, v.BgColor
+	if s.Style != (Style{}) {
+		fg, bg = s.Style.Fg, s.Style.Bg
+	}
+	v.screen.SetCell(v.x0+1, v.y0+vy+1, s.Rune, fg, bg)
+	if runeWidth(s.Rune) < 2 {
+		v.screen.SetCell(v.x0+2, v.y0+vy+1, ' ', fg, bg)
+	}
+}
+
+// replaceSigns moves the signs of the lines of the internal buffer when
+// the text from (x0, y0) to (x1, y1) is replaced, the lines y0 to y1 being
+// replaced with lines. A sign follows the start of its line: the signs of
+// a line joined to the previous one are merged with its signs, keeping
+// the signs of the previous line for the groups defined on both lines. The
+// signs of a line whose start is replaced stay on the line with the same
+// index among the new lines, or on the line with the same text if whole
+// lines are replaced, like when lines are moved. The other signs of the
+// replaced lines are dropped, ReplaceRangeCmd restores them on undo.
+func (v *View) replaceSigns(x0, y0, x1, y1 int, lines [][]cell) {
+	if len(v.signs) == 0 {
+		return
+	}
+	ys := make([]int, 0, len(v.signs))
+	for y := range v.signs {
+		ys = append(ys, y)
+	}
+	sort.Ints(ys)
+
+	last := len(lines) - 1
+	whole := x0 == 0 && x1 == len(v.lines[y1])
+	used := make([]bool, len(lines))
+	signs := make(map[int][]Sign, len(v.signs))
+	add := func(y int, ss []Sign) {
+		for _, s := range ss {
+			if !hasSignGroup(signs[y], s.Group) {
+				signs[y] = append(signs[y], s)
+			}
+		}
+	}
+	for _, y := range ys {
+		ss := v.signs[y]
+		if y < y0 || y == y0 && x0 > 0 {
+			add(y, ss)
+			continue
+		}
+		if y > y1 {
+			add(y+y0+last-y1, ss)
+			continue
+		}
+		if whole {
+			if i := sameLine(lines, used, v.lines[y]); i >= 0 {
+				used[i] = true
+				add(y0+i, ss)
+				continue
+			}
+		}
+		switch i := y - y0; {
+		case y == y0 && x0 == x1 && y0 == y1 && len(v.lines[y]) > 0:
+			// text inserted at the start of the line
+			add(y0+last, ss)
+		case y == y1 && x1 == 0 && y1 > y0:
+			// line joined to the previous one
+			add(y0+last, ss)
+		case i < last || i == last && (x1 > 0 || y1 == y0):
+			add(y, ss)
+		}
+	}
+	v.signs = signs
+}
+
+// lineSigns returns a copy of the signs of the n lines of the internal
+// buffer from the line y, nil if the view has no sign.
+func (v *View) lineSigns(y, n int) [][]Sign {
+	if len(v.signs) == 0 {
+		return nil
+	}
+	signs := make([][]Sign, n)
+	for i := range signs {
+		if ss := v.signs[y+i]; len(ss) > 0 {
+			signs[i] = append([]Sign(nil), ss...)
+		}
+	}
+	return signs
+}
+
+// restoreSigns sets the signs of the lines from the line y to the ones
+// returned by lineSigns.
+func (v *View) restoreSigns(y int, signs [][]Sign) {
+	for i, ss := range signs {
+		if len(ss) == 0 {
+			delete(v.signs, y+i)
+			continue
+		}
+		if v.signs == nil {
+			v.signs = make(map[int][]Sign)
+		}
+		v.signs[y+i] = append([]Sign(nil), ss...)
+	}
+}
+
+// sameLine returns the index of the first line of lines which is not used
+// and has the same text as line, -1 if there is none.
+func sameLine(lines [][]cell, used []bool, line []cell) int {
+	for i, l := range lines {
+		if !used[i] && cellsString(l) == cellsString(line) {
+			return i
+		}
+	}
+	return -1
+}
+
+// hasSignGroup reports whether ss contains a sign of the given group.
+func hasSignGroup(ss []Sign, group string) bool {
+	for _, s := range ss {
+		if s.Group == group {
+			return true
+		}
+	}
+	return false
+}
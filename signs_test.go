@@ -0,0 +1,143 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// signString returns the runes of the signs of the lines of v, the lines
+// being separated by spaces and "-" standing for a line without sign.
+func signString(v *View) string {
+	ls := make([]string, len(v.lines))
+	for y := range v.lines {
+		ls[y] = "-"
+		if ss := v.Signs(y); len(ss) > 0 {
+			ls[y] = ""
+			for _, s := range ss {
+				ls[y] += string(s.Rune)
+			}
+		}
+	}
+	return strings.Join(ls, " ")
+}
+
+func TestSignsUndo(t *testing.T) {
+	const initial = "- a b c"
+	tests := []struct {
+		name  string
+		steps []func(v *View) // the view is drawn after each step
+		want  string
+	}{
+		{
+			name: "insert above",
+			steps: []func(v *View){func(v *View) {
+				v.AbsMoveCursor(0, 1, false)
+				v.EditNewLine()
+			}},
+			want: "- - a b c",
+		},
+		{
+			name: "insert below",
+			steps: []func(v *View){func(v *View) {
+				v.AbsMoveCursor(3, 1, false)
+				v.EditNewLine()
+			}},
+			want: "- a - b c",
+		},
+		{
+			name: "join",
+			steps: []func(v *View){func(v *View) {
+				v.AbsMoveCursor(0, 2, false)
+				v.EditDelete(true)
+			}},
+			want: "- ab c",
+		},
+		{
+			name: "backspace run",
+			steps: []func(v *View){func(v *View) {
+				v.AbsMoveCursor(1, 3, false)
+				v.EditDelete(true)
+			}, func(v *View) {
+				v.EditDelete(true)
+			}},
+			want: "- a bc",
+		},
+		{
+			name: "delete run",
+			steps: []func(v *View){func(v *View) {
+				v.AbsMoveCursor(3, 1, false)
+				v.EditDelete(false)
+			}, func(v *View) {
+				v.EditDelete(false)
+			}},
+			want: "- ab c",
+		},
+		{
+			name: "permute",
+			steps: []func(v *View){func(v *View) {
+				v.AbsMoveCursor(0, 1, false)
+				v.EditPermutLines(false)
+			}},
+			want: "- b a c",
+		},
+		{
+			name: "delete line",
+			steps: []func(v *View){func(v *View) {
+				v.AbsMoveCursor(0, 2, false)
+				v.StartSelection(SelectLine)
+				v.DeleteSelection()
+			}},
+			want: "- a c",
+		},
+		{
+			name: "delete lines joining a sign of the same group",
+			steps: []func(v *View){func(v *View) {
+				if err := v.ReplaceRange(3, 1, 0, 3, ""); err != nil {
+					t.Fatal(err)
+				}
+			}},
+			want: "- a",
+		},
+	}
+	for _, tt := range tests {
+		var v *View
+		h, err := NewHarness(20, 10, testLayout(viewSpec{"a", 0, 0, 19, 9, func(nv *View) {
+			v = nv
+			v.Editable = true
+			v.SignColumn = true
+			fmt.Fprint(v, "aaa\nbbb\nccc\nddd")
+			v.SetSign(1, Sign{Group: "x", Rune: 'a'})
+			v.SetSign(2, Sign{Group: "y", Rune: 'b'})
+			v.SetSign(3, Sign{Group: "x", Rune: 'c'})
+		}}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, step := range tt.steps {
+			step(v)
+			if err := h.Draw(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := signString(v); got != tt.want {
+			t.Errorf("%s: signs %q, want %q", tt.name, got, tt.want)
+		}
+		v.Actions.Undo()
+		if got := signString(v); got != initial {
+			t.Errorf("%s: signs %q after undo, want %q", tt.name, got, initial)
+		}
+		if got := v.Buffer(); got != "aaa\nbbb\nccc\nddd\n" {
+			t.Errorf("%s: buffer %q after undo", tt.name, got)
+		}
+		v.Actions.Redo()
+		if got := signString(v); got != tt.want {
+			t.Errorf("%s: signs %q after redo, want %q", tt.name, got, tt.want)
+		}
+		h.Close()
+	}
+}
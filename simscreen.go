@@ -0,0 +1,299 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"bytes"
+	"sync"
+)
+
+// Cell is the content of a single position of a Screen.
+type Cell struct {
+	Ch     rune
+	Fg, Bg Attribute
+}
+
+// SimulationScreen is an in-memory Screen. It does not need a terminal,
+// events are injected by the caller and the content of the screen can be
+// inspected after each flush. It is meant to be used in tests.
+type SimulationScreen struct {
+	mu            sync.Mutex
+	width, height int
+	back, front   []Cell
+	cursorX       int
+	cursorY       int
+	cursorVisible bool
+	mouse         bool
+	colorMode     ColorMode
+	events        chan Event
+	fgClear       Attribute
+	bgClear       Attribute
+	pending       []string // sequences written by the next flush
+	sequences     []string
+}
+
+// NewSimulationScreen returns a SimulationScreen of the given size.
+func NewSimulationScreen(width, height int) *SimulationScreen {
+	s := &SimulationScreen{
+		events: make(chan Event, 256),
+	}
+	s.resize(width, height)
+	return s
+}
+
+// resize sets the dimensions of the screen and blanks its cells.
+func (s *SimulationScreen) resize(width, height int) {
+	s.width, s.height = width, height
+	s.back = make([]Cell, width*height)
+	s.front = make([]Cell, width*height)
+	for i := range s.back {
+		s.back[i] = Cell{Ch: ' ', Fg: s.fgClear, Bg: s.bgClear}
+		s.front[i] = s.back[i]
+	}
+}
+
+// Init implements Screen.
+func (s *SimulationScreen) Init() error {
+	return nil
+}
+
+// Close implements Screen.
+func (s *SimulationScreen) Close() {}
+
+// Size implements Screen.
+func (s *SimulationScreen) Size() (x, y int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.width, s.height
+}
+
+// SetCell implements Screen.
+func (s *SimulationScreen) SetCell(x, y int, ch rune, fg, bg Attribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if x < 0 || y < 0 || x >= s.width || y >= s.height {
+		return
+	}
+	s.back[y*s.width+x] = Cell{Ch: ch, Fg: fg.degrade(s.colorMode), Bg: bg.degrade(s.colorMode)}
+}
+
+// Cell implements Screen.
+func (s *SimulationScreen) Cell(x, y int) (ch rune, fg, bg Attribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if x < 0 || y < 0 || x >= s.width || y >= s.height {
+		return ' ', ColorDefault, ColorDefault
+	}
+	c := s.back[y*s.width+x]
+	return c.Ch, c.Fg, c.Bg
+}
+
+// Clear implements Screen.
+func (s *SimulationScreen) Clear(fg, bg Attribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fg, bg = fg.degrade(s.colorMode), bg.degrade(s.colorMode)
+	s.fgClear, s.bgClear = fg, bg
+	for i := range s.back {
+		s.back[i] = Cell{Ch: ' ', Fg: fg, Bg: bg}
+	}
+}
+
+// SetCursor implements Screen.
+func (s *SimulationScreen) SetCursor(x, y int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursorX, s.cursorY = x, y
+	s.cursorVisible = true
+}
+
+// HideCursor implements Screen.
+func (s *SimulationScreen) HideCursor() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursorVisible = false
+}
+
+// SetMouse implements Screen.
+func (s *SimulationScreen) SetMouse(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mouse = enabled
+}
+
+// SetColorMode implements Screen. All the color modes are supported, the
+// colors set afterwards are degraded to the given mode.
+func (s *SimulationScreen) SetColorMode(mode ColorMode) ColorMode {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.colorMode = mode
+	return mode
+}
+
+// WriteSequence implements Screen. The sequence is recorded by the next
+// flush, see Sequences.
+func (s *SimulationScreen) WriteSequence(seq string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = append(s.pending, seq)
+}
+
+// Flush implements Screen. The cells set since the last flush become
+// visible through Cells, String and AttrString.
+func (s *SimulationScreen) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copy(s.front, s.back)
+	s.sequences = append(s.sequences, s.pending...)
+	s.pending = nil
+	return nil
+}
+
+// PollEvent implements Screen. It blocks until an event is injected.
+func (s *SimulationScreen) PollEvent() Event {
+	return <-s.events
+}
+
+// nextEvent returns the next injected event, if there is one, without
+// blocking.
+func (s *SimulationScreen) nextEvent() (Event, bool) {
+	select {
+	case ev := <-s.events:
+		return ev, true
+	default:
+		return Event{}, false
+	}
+}
+
+// InjectEvent queues an event that will be returned by PollEvent.
+func (s *SimulationScreen) InjectEvent(ev Event) {
+	s.events <- ev
+}
+
+// InjectKey queues a key-press event. Either key or ch must be 0.
+func (s *SimulationScreen) InjectKey(key Key, ch rune, mod Modifier) {
+	s.InjectEvent(Event{Type: EventKey, Key: key, Ch: ch, Mod: mod})
+}
+
+// InjectString queues one key-press event for each rune of str. Spaces
+// are reported as KeySpace, like a terminal does.
+func (s *SimulationScreen) InjectString(str string) {
+	for _, ch := range str {
+		if ch == ' ' {
+			s.InjectKey(KeySpace, 0, ModNone)
+		} else {
+			s.InjectKey(0, ch, ModNone)
+		}
+	}
+}
+
+// InjectMouse queues a mouse event at the given position of the screen.
+func (s *SimulationScreen) InjectMouse(x, y int, button Key, mod Modifier) {
+	s.InjectEvent(Event{Type: EventMouse, Key: button, Mod: mod, MouseX: x, MouseY: y})
+}
+
+// InjectResize changes the size of the screen and queues the matching
+// resize event. The content of the screen is lost.
+func (s *SimulationScreen) InjectResize(width, height int) {
+	s.mu.Lock()
+	s.resize(width, height)
+	s.mu.Unlock()
+	s.InjectEvent(Event{Type: EventResize, Width: width, Height: height})
+}
+
+// Cells returns a copy of the flushed cells, row by row.
+func (s *SimulationScreen) Cells() []Cell {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cells := make([]Cell, len(s.front))
+	copy(cells, s.front)
+	return cells
+}
+
+// Sequences returns the escape sequences flushed, see
+// Screen.WriteSequence.
+func (s *SimulationScreen) Sequences() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.sequences...)
+}
+
+// CursorPosition returns the position of the cursor and whether it is
+// visible.
+func (s *SimulationScreen) CursorPosition() (x, y int, visible bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cursorX, s.cursorY, s.cursorVisible
+}
+
+// String returns the runes of the flushed cells, one line per row of the
+// screen. Like on a terminal, the cell following a wide character is
+// covered by it and is not part of the string.
+func (s *SimulationScreen) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var buf bytes.Buffer
+	for y := 0; y < s.height; y++ {
+		for x := 0; x < s.width; x++ {
+			ch := s.front[y*s.width+x].Ch
+			if ch == 0 {
+				ch = ' '
+			}
+			buf.WriteRune(ch)
+			if runeWidth(ch) == 2 {
+				x++
+			}
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// AttrString returns the colors of the flushed cells. Every distinct pair
+// of foreground and background colors is given a letter, in order of
+// appearance. The grid of letters, one line per row of the screen, is
+// followed by a legend with one line per letter. Like in String, the cell
+// following a wide character has no letter.
+func (s *SimulationScreen) AttrString() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	type colors struct{ fg, bg Attribute }
+	const keys = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	var (
+		buf    bytes.Buffer
+		legend []colors
+		index  = make(map[colors]int)
+	)
+	for y := 0; y < s.height; y++ {
+		for x := 0; x < s.width; x++ {
+			c := s.front[y*s.width+x]
+			k := colors{c.Fg, c.Bg}
+			i, ok := index[k]
+			if !ok {
+				i = len(legend)
+				index[k] = i
+				legend = append(legend, k)
+			}
+			if i < len(keys) {
+				buf.WriteByte(keys[i])
+			} else {
+				buf.WriteByte('?')
+			}
+			if runeWidth(c.Ch) == 2 {
+				x++
+			}
+		}
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	for i, k := range legend {
+		if i >= len(keys) {
+			break
+		}
+		buf.WriteString(string(keys[i]) + ": fg=" + k.fg.String() + " bg=" + k.bg.String() + "\n")
+	}
+	return buf.String()
+}
@@ -0,0 +1,56 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEditTab(t *testing.T) {
+	tests := []struct {
+		name      string
+		init      string
+		x         int // initial cursor position
+		expandTab bool
+		buffer    string
+		want      string
+		cx, cy    int
+	}{
+		{name: "expand", init: "ab", expandTab: true, buffer: "  ab\n", want: "│  ab  │", cx: 3, cy: 1},
+		{name: "expand to the next stop", init: "abc", x: 1, expandTab: true, buffer: "a bc\n", want: "│a bc  │", cx: 3, cy: 1},
+		{name: "tab rune", init: "abc", x: 1, buffer: "a\tbc\n", want: "│a bc  │", cx: 3, cy: 1},
+	}
+	for _, tt := range tests {
+		var v *View
+		h, err := NewHarness(8, 3, testLayout(viewSpec{"a", 0, 0, 7, 2, func(nv *View) {
+			v = nv
+			v.Editable = true
+			v.ExpandTab = tt.expandTab
+			v.TabWidth = 2
+			fmt.Fprint(v, tt.init)
+		}}))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		h.Gui.Cursor = true
+		v.AbsMoveCursor(tt.x, 0, false)
+		h.Screen.InjectKey(KeyTab, 0, ModNone)
+		if err := h.Run(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := v.Buffer(); got != tt.buffer {
+			t.Errorf("%s: buffer %q, want %q", tt.name, got, tt.buffer)
+		}
+		if got := strings.Split(h.Screen.String(), "\n")[1]; got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+		if x, y, _ := h.Screen.CursorPosition(); x != tt.cx || y != tt.cy {
+			t.Errorf("%s: cursor at %d,%d, want %d,%d", tt.name, x, y, tt.cx, tt.cy)
+		}
+		h.Close()
+	}
+}
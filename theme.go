@@ -0,0 +1,247 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// Style is a pair of foreground and background colors.
+type Style struct {
+	Fg, Bg Attribute
+}
+
+// Color roles of a Theme.
+const (
+	RoleDefault      = "default"       // background of the GUI
+	RoleView         = "view"          // text of the views
+	RoleFrame        = "frame"         // frames of the views
+	RoleFrameFocused = "frame-focused" // frame of the current view
+	RoleTitle        = "title"         // titles of the views
+	RoleFooter       = "footer"        // footers of the views
+	RoleSelection    = "selection"     // selected text of the views
+	RoleCursorLine   = "cursor-line"   // highlighted line of the views
+	RoleSearchMatch  = "search-match"  // matches of a search
+	RoleLineNumber   = "line-number"   // line numbers of the views
+)
+
+// Theme associates color roles with styles. Roles missing from a theme
+// fall back to the colors of the Gui and of the views. Besides the
+// predefined roles, applications can define their own ones and use them
+// for styled text.
+type Theme map[string]Style
+
+// Style returns the style of the given role.
+func (t Theme) Style(role string) (Style, bool) {
+	s, ok := t[role]
+	return s, ok
+}
+
+// LoadTheme reads a theme from a JSON file. See ParseTheme.
+func LoadTheme(path string) (Theme, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTheme(data)
+}
+
+// ParseTheme parses a JSON theme. The theme is an object associating
+// roles with objects holding the "fg" and "bg" colors, in the format
+// accepted by ParseAttribute:
+//
+//	{
+//		"default": {"fg": "white", "bg": "black"},
+//		"frame-focused": {"fg": "green|bold"},
+//		"search-match": {"fg": "black", "bg": "#ffaf00"}
+//	}
+//
+// A missing color is taken from the "default" role.
+func ParseTheme(data []byte) (Theme, error) {
+	var raw map[string]struct {
+		Fg, Bg *string
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	parse := func(role string) (Style, error) {
+		var s Style
+		r := raw[role]
+		if def, ok := raw[RoleDefault]; ok && role != RoleDefault {
+			if r.Fg == nil {
+				r.Fg = def.Fg
+			}
+			if r.Bg == nil {
+				r.Bg = def.Bg
+			}
+		}
+		var err error
+		if r.Fg != nil {
+			if s.Fg, err = ParseAttribute(*r.Fg); err != nil {
+				return s, fmt.Errorf("%s: %v", role, err)
+			}
+		}
+		if r.Bg != nil {
+			if s.Bg, err = ParseAttribute(*r.Bg); err != nil {
+				return s, fmt.Errorf("%s: %v", role, err)
+			}
+		}
+		return s, nil
+	}
+
+	t := make(Theme)
+	for role := range raw {
+		s, err := parse(role)
+		if err != nil {
+			return nil, err
+		}
+		t[role] = s
+	}
+	return t, nil
+}
+
+// ParseAttribute parses an attribute in the format returned by
+// Attribute.String: a color name ("default", "black", "red", "green",
+// "yellow", "blue", "magenta", "cyan" or "white"), an index of the 256
+// colors palette ("color(208)" or "208") or a 24-bit color ("#ff8700"),
+// optionally followed by styles ("|bold", "|underline", "|reverse").
+func ParseAttribute(s string) (Attribute, error) {
+	parts := strings.Split(s, "|")
+
+	var a Attribute
+	color := strings.ToLower(strings.TrimSpace(parts[0]))
+	switch {
+	case strings.HasPrefix(color, "#"):
+		v, err := strconv.ParseUint(color[1:], 16, 32)
+		if err != nil || len(color) != 7 {
+			return 0, fmt.Errorf("invalid color %q", parts[0])
+		}
+		a = ColorRGB(uint8(v>>16), uint8(v>>8), uint8(v))
+	case strings.HasPrefix(color, "color(") && strings.HasSuffix(color, ")"):
+		color = color[len("color(") : len(color)-1]
+		fallthrough
+	case len(color) > 0 && color[0] >= '0' && color[0] <= '9':
+		idx, err := strconv.ParseUint(color, 10, 8)
+		if err != nil {
+			return 0, fmt.Errorf("invalid color %q", parts[0])
+		}
+		a = Color256(uint8(idx))
+	default:
+		found := false
+		for i, name := range colorNames {
+			if color == name {
+				a, found = Attribute(i), true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown color %q", parts[0])
+		}
+	}
+
+	for _, p := range parts[1:] {
+		switch strings.ToLower(strings.TrimSpace(p)) {
+		case "bold":
+			a |= AttrBold
+		case "underline":
+			a |= AttrUnderline
+		case "reverse":
+			a |= AttrReverse
+		default:
+			return 0, errors.New("unknown style " + strconv.Quote(p))
+		}
+	}
+	return a, nil
+}
+
+// SetTheme sets the theme of the GUI and restyles the existing views.
+// The roles of the theme override the colors of the Gui and of the views,
+// the ones missing from the theme are reset to their defaults. Text
+// written with its own colors is not modified.
+func (g *Gui) SetTheme(t Theme) {
+	g.theme = t
+	g.FgColor, g.BgColor = defaultFgColor, defaultBgColor
+	if s, ok := t.Style(RoleDefault); ok {
+		g.FgColor, g.BgColor = s.Fg, s.Bg
+	}
+	s, _ := t.Style(RoleSelection)
+	g.SelFgColor, g.SelBgColor = s.Fg, s.Bg
+	g.applyThemeRecursively(g.viewTree)
+}
+
+// Theme returns the theme of the GUI, nil if none has been set.
+func (g *Gui) Theme() Theme {
+	return g.theme
+}
+
+func (g *Gui) applyThemeRecursively(c *Container) {
+	for _, node := range c.childrens {
+		if v, ok := node.(*View); ok {
+			g.applyTheme(v)
+		} else if cont, ok := node.(*Container); ok {
+			g.applyThemeRecursively(cont)
+		}
+	}
+}
+
+// applyTheme sets the colors of a view from the theme of the GUI.
+func (g *Gui) applyTheme(v *View) {
+	v.tainted = true
+	v.FgColor, v.BgColor = g.FgColor, g.BgColor
+	if s, ok := g.theme.Style(RoleView); ok {
+		v.FgColor, v.BgColor = s.Fg, s.Bg
+	}
+	v.SelFgColor, v.SelBgColor = g.SelFgColor, g.SelBgColor
+	// a missing role gives ColorDefault, the view then uses the colors
+	// it falls back to
+	s, _ := g.theme.Style(RoleCursorLine)
+	v.CursorLineFgColor, v.CursorLineBgColor = s.Fg, s.Bg
+	s, _ = g.theme.Style(RoleSearchMatch)
+	v.SearchFgColor, v.SearchBgColor = s.Fg, s.Bg
+	s, _ = g.theme.Style(RoleLineNumber)
+	v.NumberFgColor, v.NumberBgColor = s.Fg, s.Bg
+}
+
+// roleStyle returns the style of the given role, or the colors of the
+// GUI if the theme does not define it.
+func (g *Gui) roleStyle(role string) Style {
+	if s, ok := g.theme.Style(role); ok {
+		return s
+	}
+	return Style{Fg: g.FgColor, Bg: g.BgColor}
+}
+
+// frameStyle returns the style of the frame of a view, which depends on
+// whether the view has the focus.
+func (g *Gui) frameStyle(v *View) Style {
+	s := g.roleStyle(RoleFrame)
+	if v == g.currentView {
+		if fs, ok := g.theme.Style(RoleFrameFocused); ok {
+			return fs
+		}
+		if g.FocusFgColor != ColorDefault {
+			s.Fg = g.FocusFgColor
+		}
+		if g.FocusBgColor != ColorDefault {
+			s.Bg = g.FocusBgColor
+		}
+	}
+	return s
+}
+
+// decorationStyle returns the style of the title or the footer of a view,
+// the style of its frame if the theme does not define role.
+func (g *Gui) decorationStyle(v *View, role string) Style {
+	if s, ok := g.theme.Style(role); ok {
+		return s
+	}
+	return g.frameStyle(v)
+}
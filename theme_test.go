@@ -0,0 +1,187 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseAttribute(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Attribute
+		ok   bool
+	}{
+		{"default", ColorDefault, true},
+		{"red", ColorRed, true},
+		{" Blue | Bold ", ColorBlue | AttrBold, true},
+		{"#ff8700", ColorRGB(255, 135, 0), true},
+		{"#FF8700|underline", ColorRGB(255, 135, 0) | AttrUnderline, true},
+		{"208", Color256(208), true},
+		{"color(9)|reverse", Color256(9) | AttrReverse, true},
+		{"white|bold|underline|reverse", ColorWhite | AttrBold | AttrUnderline | AttrReverse, true},
+		{"#ff87", 0, false},
+		{"#gg8700", 0, false},
+		{"256", 0, false},
+		{"color(-1)", 0, false},
+		{"purple", 0, false},
+		{"red|blink", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseAttribute(tt.s)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseAttribute(%q): error %v", tt.s, err)
+		} else if got != tt.want {
+			t.Errorf("ParseAttribute(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+
+	// the attributes are parsed back from their string representation
+	for _, a := range []Attribute{ColorCyan | AttrBold, Color256(100), ColorRGB(1, 2, 3) | AttrReverse} {
+		if got, err := ParseAttribute(a.String()); err != nil || got != a {
+			t.Errorf("ParseAttribute(%q) = %v, %v", a.String(), got, err)
+		}
+	}
+}
+
+func TestThemeRoles(t *testing.T) {
+	tests := []struct {
+		name  string
+		theme string
+		want  string
+	}{
+		{
+			name: "all roles",
+			theme: `{
+				"default": {"fg": "white", "bg": "black"},
+				"selection": {"fg": "red", "bg": "blue"},
+				"cursor-line": {"bg": "green"},
+				"search-match": {"fg": "black", "bg": "yellow"}
+			}`,
+			want: lines(
+				"aaaaaaaa",
+				"abbabbaa",
+				"acbbdaaa",
+				"aaaaaaaa",
+				"",
+				"a: fg=white bg=black",
+				"b: fg=black bg=yellow",
+				"c: fg=red bg=blue",
+				"d: fg=white bg=green",
+			),
+		},
+		{
+			name: "selection colors",
+			theme: `{
+				"default": {"fg": "white", "bg": "black"},
+				"selection": {"fg": "red", "bg": "blue"}
+			}`,
+			want: lines(
+				"aaaaaaaa",
+				"abbabbaa",
+				"abbbbaaa",
+				"aaaaaaaa",
+				"",
+				"a: fg=white bg=black",
+				"b: fg=red bg=blue",
+			),
+		},
+	}
+	for _, tt := range tests {
+		theme, err := ParseTheme([]byte(tt.theme))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var v *View
+		h, err := NewHarness(8, 4, testLayout(viewSpec{"a", 0, 0, 7, 3, func(nv *View) {
+			v = nv
+			v.Highlight = true
+			v.HighlightSearch = true
+			fmt.Fprint(v, "ab ab\nxabc")
+		}}))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		h.Gui.SetTheme(theme)
+		if ok, _, _ := v.SearchForward("ab"); !ok {
+			t.Fatalf("%s: no match", tt.name)
+		}
+		v.SetCursor(0, 1)
+		v.StartSelection(SelectChar)
+		v.SetCursor(1, 1)
+		if err := h.Draw(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := h.Screen.AttrString(); got != tt.want {
+			t.Errorf("%s: got\n%swant\n%s", tt.name, got, tt.want)
+		}
+		h.Close()
+	}
+}
+
+func TestThemeSwitch(t *testing.T) {
+	a, err := ParseTheme([]byte(`{
+		"default": {"fg": "yellow", "bg": "blue"},
+		"selection": {"fg": "red", "bg": "cyan"},
+		"cursor-line": {"bg": "green"},
+		"line-number": {"fg": "magenta"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v *View
+	h, err := NewHarness(8, 4, testLayout(viewSpec{"a", 0, 0, 7, 3, func(nv *View) {
+		v = nv
+		v.Highlight = true
+		v.LineNumbers = LineNumbersAbsolute
+		fmt.Fprint(v, "ab\ncd")
+	}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	h.Gui.SetTheme(a)
+	if err := h.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	want := lines(
+		"aaaaaaaa",
+		"abbccaaa",
+		"abbaaaaa",
+		"aaaaaaaa",
+		"",
+		"a: fg=yellow bg=blue",
+		"b: fg=magenta bg=blue",
+		"c: fg=yellow bg=green",
+	)
+	if got := h.Screen.AttrString(); got != want {
+		t.Errorf("theme a: got\n%swant\n%s", got, want)
+	}
+
+	// the roles missing from the new theme are reset, the cursor line
+	// falls back to the default selection colors
+	h.Gui.SetTheme(Theme{})
+	if err := h.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	if h.Gui.SelFgColor != ColorDefault || h.Gui.SelBgColor != ColorDefault {
+		t.Errorf("selection colors %v, %v", h.Gui.SelFgColor, h.Gui.SelBgColor)
+	}
+	want = lines(
+		"aaaaaaaa",
+		"aaabbaaa",
+		"aaaaaaaa",
+		"aaaaaaaa",
+		"",
+		"a: fg=white bg=black",
+		"b: fg=default bg=default",
+	)
+	if got := h.Screen.AttrString(); got != want {
+		t.Errorf("empty theme: got\n%swant\n%s", got, want)
+	}
+}
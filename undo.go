@@ -3,6 +3,8 @@ package gocui
 import (
 	"fmt"
 	"reflect"
+	"strings"
+	"time"
 )
 
 // Should be implemented by every command
@@ -13,9 +15,10 @@ type Command interface {
 }
 
 // It should be implemented by a command,
-// if 2 successive commands of the same type have to merge
+// if 2 successive commands of the same type can merge.
+// merge returns false if m cannot be merged with the command.
 type Mergeable interface {
-	merge(m Mergeable)
+	merge(m Mergeable) bool
 }
 
 // ActionsInterface should be implemented by our Context
@@ -26,10 +29,53 @@ type ActionsInterface interface {
 }
 
 // Implements ActionsInterface
+// The commands are kept in a tree: a command executed after an undo starts
+// a new branch, and the previous branch can still be reached, see GoTo.
 type Context struct {
-	merge  bool
-	undoSt CmdStack
-	redoSt CmdStack
+	merge bool
+	root  *undoNode   // initial state, created by the first command
+	cur   *undoNode   // current state
+	nodes []*undoNode // states by sequence number
+	now   func() time.Time
+
+	limits UndoLimits
+	bytes  int // estimated memory used by the states
+
+	group      *GroupCmd // group being built, nil if there is none
+	groupDepth int       // number of nested calls to BeginGroup
+}
+
+// GroupCmd is a sequence of commands undone and redone as a single one,
+// see Context.BeginGroup.
+type GroupCmd struct {
+	name string
+	cmds CmdStack
+}
+
+// Executes the commands of the group in order
+func (g *GroupCmd) Execute() {
+	for _, c := range g.cmds {
+		c.Execute()
+	}
+}
+
+// Reverses the commands of the group in reverse order
+func (g *GroupCmd) Reverse() {
+	for i := len(g.cmds) - 1; i >= 0; i-- {
+		g.cmds[i].Reverse()
+	}
+}
+
+func (g *GroupCmd) Info() string {
+	if g.name != "" {
+		return g.name
+	}
+	return fmt.Sprintf("%d command(s)", len(g.cmds))
+}
+
+// Commands returns the commands of the group, in execution order.
+func (g *GroupCmd) Commands() []Command {
+	return g.cmds
 }
 
 // Is used as a stack of Command
@@ -64,114 +110,189 @@ func (con *Context) Cut() {
 	con.merge = false
 }
 
-// Adds a command to the undo stack,
+// Adds a command to the undo tree, or to the current group,
 // merging it with the last command if possible.
-// Clears the redo stack
+// The command starts a new branch if some commands have been undone.
 func (con *Context) Exec(c Command) {
-	if con.merge {
-		if _, ok := c.(Mergeable); ok {
-			if l := len(con.undoSt); l > 0 {
-				pr := con.undoSt[l-1]
-				if reflect.TypeOf(pr) == reflect.TypeOf(c) {
-					pr.(Mergeable).merge(c.(Mergeable))
-					return
-				}
-			}
+	if g := con.group; g != nil {
+		if !con.merge || len(g.cmds) == 0 || !mergeCommand(g.cmds[len(g.cmds)-1], c) {
+			g.cmds.Push(c)
 		}
+		con.merge = true
+		return
+	}
+	con.init()
+	if con.merge && con.cur != con.root && mergeCommand(con.cur.cmd, c) {
+		n := con.cur
+		n.time = con.clock()
+		con.bytes -= n.size
+		n.size = nodeOverhead + commandSize(n.cmd)
+		con.bytes += n.size
+		con.trim()
+		return
 	}
 	con.merge = true
-	con.undoSt.Push(c)
-	con.redoSt.Clear()
+	con.push(c)
 }
 
-// Moves a command from the undo stack to the redo stack and reverses it.
-func (con *Context) Undo() {
-	if c := con.undoSt.Pop(); c != nil {
-		con.redoSt.Push(c)
+// mergeCommand merges c with pr if they have the same type and are
+// mergeable. It returns false if c must be pushed.
+func mergeCommand(pr, c Command) bool {
+	if _, ok := c.(Mergeable); !ok {
+		return false
+	}
+	if reflect.TypeOf(pr) != reflect.TypeOf(c) {
+		return false
+	}
+	return pr.(Mergeable).merge(c.(Mergeable))
+}
+
+// BeginGroup starts a group of commands: the commands executed until the
+// matching call to EndGroup are undone and redone as a single command,
+// named after name. Groups can be nested, the commands of the inner
+// groups belong to the outermost one.
+func (con *Context) BeginGroup(name string) {
+	if con.groupDepth == 0 {
+		con.group = &GroupCmd{name: name}
 		con.merge = false
-		c.Reverse()
 	}
+	con.groupDepth++
 }
 
-// Moves a command from the redo stack to the undo stack and executes it.
-func (con *Context) Redo() {
-	if c := con.redoSt.Pop(); c != nil {
-		con.undoSt.Push(c)
+// EndGroup ends the group started by the last call to BeginGroup. The
+// group is added to the undo stack when the outermost group ends, unless
+// it is empty.
+func (con *Context) EndGroup() {
+	if con.groupDepth == 0 {
+		return
+	}
+	if con.groupDepth--; con.groupDepth > 0 {
+		return
+	}
+	if len(con.group.cmds) > 0 {
+		con.init()
+		con.push(con.group)
+	}
+	con.group = nil
+	con.merge = false
+}
+
+// Transaction runs f in a group of commands, see BeginGroup. If f returns
+// an error, the commands it executed are reversed and discarded, and the
+// error is returned.
+func (con *Context) Transaction(name string, f func() error) error {
+	con.BeginGroup(name)
+	defer con.EndGroup()
+	con.merge = false
+	g, n := con.group, len(con.group.cmds)
+	err := f()
+	if err != nil && con.group == g {
+		for i := len(g.cmds) - 1; i >= n; i-- {
+			g.cmds[i].Reverse()
+		}
+		g.cmds = g.cmds[:n]
 		con.merge = false
-		c.Execute()
 	}
+	return err
 }
 
-// Returns a formatted string representation of the historic
+// endGroups ends the groups which are still open.
+func (con *Context) endGroups() {
+	for con.groupDepth > 0 {
+		con.EndGroup()
+	}
+}
+
+// Reverses the command leading to the current state and moves to the
+// previous state. The groups which are still open are ended first.
+func (con *Context) Undo() {
+	con.endGroups()
+	if con.cur == nil || con.cur == con.root {
+		return
+	}
+	n := con.cur
+	con.cur = n.parent
+	con.merge = false
+	n.cmd.Reverse()
+}
+
+// Executes the command leading to the next state of the current branch
+// and moves to this state. The groups which are still open are ended first.
+func (con *Context) Redo() {
+	con.endGroups()
+	if con.cur == nil || len(con.cur.children) == 0 {
+		return
+	}
+	n := con.cur.children[con.cur.next]
+	con.cur = n
+	con.merge = false
+	n.cmd.Execute()
+}
+
+// Returns a formatted string representation of the historic: the commands
+// of the current branch, around the current state. A command starting one
+// of several branches is followed by its index among them.
 func (con *Context) ToString(w, h int) string {
-	s := make([]byte, 2000)
-	l := 0
+	var b strings.Builder
+	line := func(n *undoNode) {
+		info := n.cmd.Info()
+		if k := len(n.parent.children); k > 1 {
+			info += fmt.Sprintf(" [%d/%d]", n.branch+1, k)
+		}
+		if len(info) >= w {
+			// changes the last 3 char to dots if the info is too long
+			info = info[:w-3] + "..."
+		}
+		b.WriteString(info + "\n")
+	}
+
+	// commands to undo, from the oldest one
+	var undo []*undoNode
+	for n := con.cur; n != nil && n != con.root; n = n.parent {
+		undo = append([]*undoNode{n}, undo...)
+	}
+	// commands to redo, from the next one
+	var redo []*undoNode
+	for n := con.cur; n != nil && len(n.children) > 0; {
+		n = n.children[n.next]
+		redo = append(redo, n)
+	}
 
-	var le int = len(con.undoSt)
+	var le int = len(undo)
 	var offs int = 0
 	// pads to stabilize the position of " - UNDO - "
 	if le > h/2-1 {
 		offs = le - h/2 + 1
 	} else if le < 2 {
-		for i := 0; i < h/2-1; i++ {
-			l += copy(s[l:], "\n")
-		}
+		b.WriteString(strings.Repeat("\n", h/2-1))
 	} else {
-		for i := 0; i < h/2-le; i++ {
-			l += copy(s[l:], "\n")
-		}
+		b.WriteString(strings.Repeat("\n", h/2-le))
 	}
 
-	// prints the most recent commands in the undo stack
+	// prints the most recent commands to undo
 	for i := offs; i < le-1; i++ {
-		info := con.undoSt[i].Info()
-		if len(info) < w {
-			l += copy(s[l:], info+"\n")
-		} else {
-			// changes the last 3 char to dots if the info is too long
-			l += copy(s[l:], info[:w-3]+"..."+"\n")
-		}
+		line(undo[i])
 	}
-	l += copy(s[l:], "     - UNDO -\n")
+	b.WriteString("     - UNDO -\n")
 	if le > 0 {
-		info := con.undoSt[le-1].Info()
-		if len(info) < w {
-			l += copy(s[l:], info+"\n")
-		} else {
-			l += copy(s[l:], info[:w-3]+"..."+"\n")
-		}
+		line(undo[le-1])
 	} else {
-		l += copy(s[l:], "\n")
+		b.WriteString("\n")
 	}
-	l += copy(s[l:], "     - REDO -\n")
-	le = len(con.redoSt)
-	offs = 0
-	// prints the most recent commands in the undo stack
-	if le > 0 {
-		info := con.redoSt[le-1].Info()
-		if len(info) < w {
-			l += copy(s[l:], info+"\n")
-		} else {
-			l += copy(s[l:], info[:w-3]+"..."+"\n")
-		}
+	b.WriteString("     - REDO -\n")
+	if len(redo) > 0 {
+		line(redo[0])
 	} else {
-		l += copy(s[l:], "\n")
+		b.WriteString("\n")
 	}
-	l += copy(s[l:], "     -      -\n")
+	b.WriteString("     -      -\n")
 
-	if le > h/2-1 {
-		offs = le - h/2 + 1
-	}
-	for i := len(con.redoSt) - 2; i >= offs; i-- {
-		info := con.redoSt[i].Info()
-		if len(info) < w {
-			l += copy(s[l:], info+"\n")
-		} else {
-			l += copy(s[l:], info[:w-3]+"..."+"\n")
-		}
+	// prints the next commands to redo
+	for i := 1; i < len(redo) && i < h/2-1; i++ {
+		line(redo[i])
 	}
 
-	return string(s)
+	return b.String()
 }
 
 func (g *Gui) UpdateHistoric() {
@@ -179,7 +300,10 @@ func (g *Gui) UpdateHistoric() {
 	var vm, vh *View
 
 	vm = g.CurrentView()
-	vh, _ = g.View("historic")
+	vh, err := g.View("historic")
+	if vm == nil || err != nil {
+		return
+	}
 	w, h := vh.Size()
 
 	vh.Clear()
@@ -2,6 +2,7 @@ package gocui
 
 import (
 	"fmt"
+	"strings"
 )
 
 /*
@@ -13,366 +14,141 @@ In concrete terms, it means that x,y are the return values of
 realPosition called on the cursor position before the actual edition.
 */
 
-/*
-// ---------------------- PASTE CMD ------------------------- //
-
-type PasteCmd struct {
-	v    *View
-	x, y int
-	act  []Command
-}
-
-func NewPasteCmd(v *View, x, y int) *PasteCmd {
-	return &PasteCmd{v: v, x: x, y: y}
-}
-
-func (c *PasteCmd) Execute() {
-	for _, cmd := range c.act {
-		cmd.Execute()
-	}
-}
-
-func (c *PasteCmd) Reverse() {
-	for i, _ := range c.act {
-		c.act[i].Reverse()
-	}
-}
-
-func (c *PasteCmd) Info() string {
-	return "Paste"
-}
-
-func (c *PasteCmd) merge(m Mergeable) {
-	if o, ok := m.(*Command); ok {
-		c.act = append(c.act, o)
-	}
-}
-*/
-
-// ---------------------- UPPERMUT CMD ------------------------- //
-
-type UpPermutCmd struct {
-	v    *View
-	x, y int
-	n    int // relative position of the destination line
-}
-
-func NewUpPermutCmd(v *View, x, y int, n int) *UpPermutCmd {
-	return &UpPermutCmd{v: v, x: x, y: y, n: n}
-}
-
-func (c *UpPermutCmd) Execute() {
-	for i := c.y; i > c.y+c.n; i-- {
-		c.v.permutLines(i, i-1)
-	}
-	c.v.AbsMoveCursor(c.x, c.y+c.n, false)
-}
-
-func (c *UpPermutCmd) Reverse() {
-	for i := c.y + c.n; i < c.y; i++ {
-		c.v.permutLines(i, i+1)
-	}
-	c.v.AbsMoveCursor(c.x, c.y, false)
-}
-
-func (c *UpPermutCmd) Info() string {
-	return fmt.Sprintf("MoveLine %d -> %d", c.y+1, c.y+c.n+1)
-}
-
-func (c *UpPermutCmd) merge(m Mergeable) {
-	if o, ok := m.(*UpPermutCmd); ok {
-		c.n += o.n
-	}
-}
-
-// ---------------------- DOWNPERMUT CMD ------------------------- //
-
-type DownPermutCmd struct {
-	v    *View
-	x, y int
-	n    int // relative position of the destination line
-}
-
-func NewDownPermutCmd(v *View, x, y int, n int) *DownPermutCmd {
-	return &DownPermutCmd{v: v, x: x, y: y, n: n}
-}
+// ---------------------- REPLACERANGE CMD ------------------------- //
 
-func (c *DownPermutCmd) Execute() {
-	for i := c.y; i < c.y+c.n; i++ {
-		c.v.permutLines(i, i+1)
-	}
-	c.v.AbsMoveCursor(c.x, c.y+c.n, false)
-}
-
-func (c *DownPermutCmd) Reverse() {
-	for i := c.y + c.n; i > c.y; i-- {
-		c.v.permutLines(i, i-1)
-	}
-	c.v.AbsMoveCursor(c.x, c.y, false)
-}
-
-func (c *DownPermutCmd) Info() string {
-	return fmt.Sprintf("MoveLine %d -> %d", c.y+1, c.y+c.n+1)
-}
-
-func (c *DownPermutCmd) merge(m Mergeable) {
-	if o, ok := m.(*DownPermutCmd); ok {
-		c.n += o.n
-	}
+// ReplaceRangeCmd replaces a range of the internal buffer with a text.
+// Every edit of a view is a ReplaceRangeCmd: an insertion replaces an empty
+// range, a deletion replaces a range with an empty text.
+type ReplaceRangeCmd struct {
+	v        *View
+	x, y     int
+	x0, y0   int      // start of the replaced range
+	old, new [][]cell // text before and after the replacement, by line
+	ax, ay   int      // position of the cursor after the replacement
+	// signs of the lines of old and new, when the text was there, nil if
+	// they are not known
+	signs, newSigns [][]Sign
 }
 
-// ---------------------- FWDDELLINE CMD ------------------------- //
-
-type FwdDelLineCmd struct {
-	v    *View
-	x, y int
-	n    int
-}
-
-func NewFwdDelLineCmd(v *View, x, y int) *FwdDelLineCmd {
-	return &FwdDelLineCmd{v: v, x: x, y: y, n: 1}
+// NewReplaceRangeCmd returns the command replacing old, at (x0, y0), with
+// new. The texts are made of lines separated by line breaks, they have at
+// least one line. The signs of the lines of old are kept, so they are
+// restored when the command is reversed, and the ones of the lines of new
+// are restored when it is executed again.
+func NewReplaceRangeCmd(v *View, x, y, x0, y0 int, old, new [][]cell, ax, ay int) *ReplaceRangeCmd {
+	return &ReplaceRangeCmd{v: v, x: x, y: y, x0: x0, y0: y0, old: old, new: new, ax: ax, ay: ay, signs: v.lineSigns(y0, len(old))}
 }
 
-func (c *FwdDelLineCmd) Execute() {
-	for i := 0; i < c.n; i++ {
-		c.v.absMergeLines(c.y)
+func (c *ReplaceRangeCmd) Execute() {
+	x1, y1 := textEnd(c.x0, c.y0, c.old)
+	c.signs = c.v.lineSigns(c.y0, len(c.old))
+	c.v.replaceText(c.x0, c.y0, x1, y1, c.new)
+	if c.newSigns != nil {
+		c.v.restoreSigns(c.y0, c.newSigns)
 	}
-	c.v.AbsMoveCursor(c.x, c.y, false)
+	c.v.AbsMoveCursor(c.ax, c.ay, false)
 }
 
-func (c *FwdDelLineCmd) Reverse() {
-	for i := 0; i < c.n; i++ {
-		c.v.absBreakLine(c.x, c.y)
+func (c *ReplaceRangeCmd) Reverse() {
+	x1, y1 := textEnd(c.x0, c.y0, c.new)
+	c.newSigns = c.v.lineSigns(c.y0, len(c.new))
+	c.v.replaceText(c.x0, c.y0, x1, y1, c.old)
+	if c.signs != nil {
+		c.v.restoreSigns(c.y0, c.signs)
 	}
 	c.v.AbsMoveCursor(c.x, c.y, false)
 }
 
-func (c *FwdDelLineCmd) Info() string {
-	return fmt.Sprintf("%d FwdDelLine(s)", c.n)
-}
-
-func (c *FwdDelLineCmd) merge(m Mergeable) {
-	if _, ok := m.(*FwdDelLineCmd); ok {
-		c.n++
-	}
-}
-
-// ---------------------- BACKDELLINE CMD ------------------------- //
-
-type BackDelLineCmd struct {
-	v *View
-	// this is
-	px, py int
-	n      int
-}
-
-func NewBackDelLineCmd(v *View, px, py int) *BackDelLineCmd {
-	return &BackDelLineCmd{v: v, px: px, py: py, n: 1}
-}
-
-func (c *BackDelLineCmd) Execute() {
-	for i := 0; i < c.n; i++ {
-		c.v.absMergeLines(c.py)
-	}
-	c.v.AbsMoveCursor(c.px, c.py, false)
-}
-
-func (c *BackDelLineCmd) Reverse() {
-	c.v.absBreakLine(c.px, c.py)
-	for i := 1; i < c.n; i++ {
-		c.v.absBreakLine(0, c.py+i)
-	}
-	c.v.AbsMoveCursor(0, c.py+c.n, false)
-}
-
-func (c *BackDelLineCmd) Info() string {
-	return fmt.Sprintf("%d DelLine(s)", c.n)
-}
-
-func (c *BackDelLineCmd) merge(m Mergeable) {
-	if o, ok := m.(*BackDelLineCmd); ok {
-		c.py = o.py
-		c.px = o.px
-		c.n++
-	}
-}
-
-// ---------------------- BACKDEL CMD ------------------------- //
-
-type BackDeleteCmd struct {
-	v    *View
-	x, y int
-	p    []rune // deleted
-}
-
-func NewBackDeleteCmd(v *View, x, y int, fchar rune) *BackDeleteCmd {
-	return &BackDeleteCmd{v: v, x: x, y: y, p: []rune{fchar}}
-}
-
-func (c *BackDeleteCmd) Execute() {
-	for i := 0; i < len(c.p); i++ {
-		c.v.absDeleteRune(c.x-i-1, c.y)
-	}
-	c.v.AbsMoveCursor(c.x-len(c.p), c.y, false)
-}
-
-func (c *BackDeleteCmd) Reverse() {
-	for i, ch := range c.p {
-		c.v.absWriteRune(c.x-len(c.p)+i, c.y, ch)
-	}
-	c.v.AbsMoveCursor(c.x, c.y, false)
-}
-
-func (c *BackDeleteCmd) Info() string {
-	return "Delete : " + string(c.p)
-}
-
-func (c *BackDeleteCmd) merge(m Mergeable) {
-	if o, ok := m.(*BackDeleteCmd); ok {
-		c.p = append(o.p, c.p...)
-	}
-}
-
-// ---------------------- FWDDEL CMD ------------------------- //
-
-type FwdDeleteCmd struct {
-	v    *View
-	x, y int
-	p    []rune // word thats been deleted
-}
-
-func NewFwdDeleteCmd(v *View, x, y int, fchar rune) *FwdDeleteCmd {
-	return &FwdDeleteCmd{v: v, x: x, y: y, p: []rune{fchar}}
-}
-
-func (c *FwdDeleteCmd) Execute() {
-	for i := 0; i < len(c.p); i++ {
-		c.v.absDeleteRune(c.x, c.y)
-	}
-	c.v.AbsMoveCursor(c.x-len(c.p)+1, c.y, false)
-}
-
-func (c *FwdDeleteCmd) Reverse() {
-	for i := len(c.p) - 1; i >= 0; i-- {
-		c.v.absWriteRune(c.x, c.y, c.p[i])
-	}
-	c.v.AbsMoveCursor(c.x+len(c.p), c.y, false)
-}
-
-func (c *FwdDeleteCmd) Info() string {
-	return "Delete : " + string(c.p)
-}
-
-func (c *FwdDeleteCmd) merge(m Mergeable) {
-	if o, ok := m.(*FwdDeleteCmd); ok {
-		c.p = append(c.p, o.p...)
-	}
-}
-
-// ---------------------- NEWLINE CMD ------------------------- //
-
-type NewLineCmd struct {
-	v    *View
-	x, y int
-	n    int // nb of successive newlines made
-}
-
-func NewNewLineCmd(v *View, x, y int) *NewLineCmd {
-	return &NewLineCmd{v: v, x: x, y: y, n: 1}
-}
-
-func (c *NewLineCmd) Execute() {
-	for i := 0; i < c.n; i++ {
-		c.v.absBreakLine(c.x, c.y)
-	}
-	c.v.AbsMoveCursor(c.x+c.n, c.y, false)
-}
-
-func (c *NewLineCmd) Reverse() {
-	for i := 0; i < c.n; i++ {
-		c.v.absMergeLines(c.y)
-	}
-	c.v.AbsMoveCursor(c.x, c.y, false)
-}
-
-func (c *NewLineCmd) Info() string {
-	return fmt.Sprintf("%d NewLine(s)", c.n)
-}
-
-func (c *NewLineCmd) merge(m Mergeable) {
-	if _, ok := m.(*NewLineCmd); ok {
-		c.n++
-	}
-}
-
-// ---------------------- SPACE CMD ------------------------- //
-
-type SpaceCmd struct {
-	v    *View
-	x, y int
-	n    int // nb of successive spaces made
-}
-
-func NewSpaceCmd(v *View, x, y int) *SpaceCmd {
-	return &SpaceCmd{v: v, x: x, y: y, n: 1}
-}
-
-func (c *SpaceCmd) Execute() {
-	for i := 0; i < c.n; i++ {
-		c.v.absWriteRune(c.x, c.y, ' ')
-	}
-	c.v.AbsMoveCursor(c.x+c.n+1, c.y, false)
-}
-
-func (c *SpaceCmd) Reverse() {
-	for i := 0; i < c.n; i++ {
-		c.v.absDeleteRune(c.x, c.y)
-	}
-	c.v.AbsMoveCursor(c.x+1, c.y, false)
-}
-
-func (c *SpaceCmd) Info() string {
-	return fmt.Sprintf("%d Spaces", c.n)
-}
-
-func (c *SpaceCmd) merge(m Mergeable) {
-	if _, ok := m.(*SpaceCmd); ok {
-		c.n++
-	}
-}
-
-// ---------------------- WRITE CMD ------------------------- //
-
-type WriteCmd struct {
-	v    *View
-	x, y int
-	p    []rune // word thats been written
-}
-
-func NewWriteCmd(v *View, x, y int, fchar rune) *WriteCmd {
-	return &WriteCmd{v: v, x: x, y: y, p: []rune{fchar}}
-}
-
-func (c *WriteCmd) Execute() {
-	for i := len(c.p) - 1; i >= 0; i-- {
-		c.v.absWriteRune(c.x, c.y, c.p[i])
-	}
-	c.v.AbsMoveCursor(c.x+len(c.p), c.y, false)
-}
-
-func (c *WriteCmd) Reverse() {
-	for i := 0; i < len(c.p); i++ {
-		c.v.absDeleteRune(c.x, c.y)
-	}
-	c.v.AbsMoveCursor(c.x, c.y, false)
-}
-
-func (c *WriteCmd) Info() string {
-	return "Write : " + string(c.p)
-}
-
-func (c *WriteCmd) merge(m Mergeable) {
-	if o, ok := m.(*WriteCmd); ok {
-		c.p = append(c.p, o.p...)
-	}
+func (c *ReplaceRangeCmd) Info() string {
+	switch c.kind() {
+	case "space":
+		return fmt.Sprintf("%d Spaces", len(c.new[0]))
+	case "newline":
+		return fmt.Sprintf("%d NewLine(s)", len(c.new)-1)
+	case "write":
+		return "Write : " + textString(c.new)
+	case "delete":
+		return "Delete : " + textString(c.old)
+	}
+	return fmt.Sprintf("Replace %d line(s)", len(c.old))
+}
+
+// kind returns the kind of edit made by the command, only the commands of
+// the same kind are merged.
+func (c *ReplaceRangeCmd) kind() string {
+	switch {
+	case !isEmptyText(c.old) && !isEmptyText(c.new):
+		return "replace"
+	case isEmptyText(c.new):
+		return "delete"
+	case len(c.new) == 1 && strings.Trim(cellsString(c.new[0]), " ") == "":
+		return "space"
+	}
+	for _, l := range c.new {
+		if len(l) > 0 {
+			return "write"
+		}
+	}
+	return "newline"
+}
+
+// merge merges m, executed after c, with c if they are of the same kind
+// and contiguous: m starts at the end of the text written by c, like when
+// typing or deleting forward, or m ends at the start of c, like when
+// deleting backward.
+func (c *ReplaceRangeCmd) merge(m Mergeable) bool {
+	o, ok := m.(*ReplaceRangeCmd)
+	if !ok || o.v != c.v || o.kind() != c.kind() {
+		return false
+	}
+	ex, ey := textEnd(c.x0, c.y0, c.new)
+	ox, oy := textEnd(o.x0, o.y0, o.old)
+	// the signs of the line shared by the two commands are the ones it had
+	// before c
+	cSigns, oSigns := c.signs, o.signs
+	if cSigns == nil && oSigns != nil {
+		cSigns = make([][]Sign, len(c.old))
+	} else if oSigns == nil && cSigns != nil {
+		oSigns = make([][]Sign, len(o.old))
+	}
+	switch {
+	case o.x0 == ex && o.y0 == ey:
+		c.old, c.new = joinText(c.old, o.old), joinText(c.new, o.new)
+		if cSigns != nil {
+			c.signs = append(cSigns, oSigns[1:]...)
+		}
+	case ox == c.x0 && oy == c.y0:
+		c.x0, c.y0 = o.x0, o.y0
+		c.old, c.new = joinText(o.old, c.old), joinText(o.new, c.new)
+		if cSigns != nil {
+			c.signs = append(oSigns[:len(oSigns)-1:len(oSigns)-1], cSigns...)
+		}
+	default:
+		return false
+	}
+	c.ax, c.ay = o.ax, o.ay
+	return true
+}
+
+// joinText returns the text a followed by the text b.
+func joinText(a, b [][]cell) [][]cell {
+	last := len(a) - 1
+	text := make([][]cell, 0, len(a)+len(b)-1)
+	text = append(text, a[:last]...)
+	text = append(text, append(append([]cell(nil), a[last]...), b[0]...))
+	return append(text, b[1:]...)
+}
+
+// isEmptyText reports whether text has no character and no line break.
+func isEmptyText(text [][]cell) bool {
+	return len(text) == 1 && len(text[0]) == 0
+}
+
+// textString returns text as a string, line breaks being written \n.
+func textString(text [][]cell) string {
+	lines := make([]string, len(text))
+	for i, l := range text {
+		lines[i] = cellsString(l)
+	}
+	return strings.Join(lines, `\n`)
 }
@@ -0,0 +1,194 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestReplaceRangeMerge(t *testing.T) {
+	tests := []struct {
+		name      string
+		x, y      int             // initial cursor position
+		overwrite bool            // overwrite mode of the view
+		steps     []func(v *View) // the view is drawn after each step
+		buffer    string
+		info      string // info of the last command
+		commands  int
+		cx, cy    int // cursor position after undoing the commands
+	}{
+		{
+			name: "type",
+			x:    1,
+			steps: []func(v *View){
+				func(v *View) { v.EditWrite('x') },
+				func(v *View) { v.EditWrite('y') },
+			},
+			buffer:   "axybc\ndef\nghi\n",
+			info:     "Write : xy",
+			commands: 1,
+			cx:       1,
+		},
+		{
+			name: "type a word and a space",
+			x:    1,
+			steps: []func(v *View){
+				func(v *View) { v.EditWrite('x') },
+				func(v *View) { v.EditWrite(' ') },
+				func(v *View) { v.EditWrite(' ') },
+			},
+			buffer:   "ax  bc\ndef\nghi\n",
+			info:     "2 Spaces",
+			commands: 2,
+			cx:       1,
+		},
+		{
+			name: "type at two positions",
+			x:    1,
+			steps: []func(v *View){
+				func(v *View) { v.EditWrite('x') },
+				func(v *View) { v.AbsMoveCursor(0, 1, false) },
+				func(v *View) { v.EditWrite('y') },
+			},
+			buffer:   "axbc\nydef\nghi\n",
+			info:     "Write : y",
+			commands: 2,
+			cx:       1,
+		},
+		{
+			name: "new lines",
+			x:    3,
+			steps: []func(v *View){
+				func(v *View) { v.EditNewLine() },
+				func(v *View) { v.EditNewLine() },
+			},
+			buffer:   "abc\n\n\ndef\nghi\n",
+			info:     "2 NewLine(s)",
+			commands: 1,
+			cx:       3,
+		},
+		{
+			name: "backspace run",
+			x:    1,
+			y:    1,
+			steps: []func(v *View){
+				func(v *View) { v.EditDelete(true) },
+				func(v *View) { v.EditDelete(true) },
+				func(v *View) { v.EditDelete(true) },
+			},
+			buffer:   "abef\nghi\n",
+			info:     `Delete : c\nd`,
+			commands: 1,
+			cx:       1,
+			cy:       1,
+		},
+		{
+			name: "delete run",
+			x:    2,
+			steps: []func(v *View){
+				func(v *View) { v.EditDelete(false) },
+				func(v *View) { v.EditDelete(false) },
+				func(v *View) { v.EditDelete(false) },
+			},
+			buffer:   "abef\nghi\n",
+			info:     `Delete : c\nd`,
+			commands: 1,
+			cx:       2,
+		},
+		{
+			name: "backspace and delete",
+			x:    2,
+			steps: []func(v *View){
+				func(v *View) { v.EditDelete(true) },
+				func(v *View) { v.EditDelete(false) },
+			},
+			buffer:   "a\ndef\nghi\n",
+			info:     "Delete : bc",
+			commands: 1,
+			cx:       2,
+		},
+		{
+			name:      "overwrite",
+			x:         1,
+			overwrite: true,
+			steps: []func(v *View){
+				func(v *View) { v.EditWrite('x') },
+				func(v *View) { v.EditWrite('y') },
+			},
+			buffer:   "axy\ndef\nghi\n",
+			info:     "Replace 1 line(s)",
+			commands: 1,
+			cx:       1,
+		},
+		{
+			// the last character is written after the end of the line
+			name:      "overwrite past the end",
+			x:         1,
+			overwrite: true,
+			steps: []func(v *View){
+				func(v *View) { v.EditWrite('x') },
+				func(v *View) { v.EditWrite('y') },
+				func(v *View) { v.EditWrite('z') },
+			},
+			buffer:   "axyz\ndef\nghi\n",
+			info:     "Write : z",
+			commands: 2,
+			cx:       1,
+		},
+		{
+			name: "permute",
+			steps: []func(v *View){
+				func(v *View) { v.EditPermutLines(false) },
+				func(v *View) { v.EditPermutLines(false) },
+			},
+			buffer:   "def\nghi\nabc\n",
+			info:     "Replace 2 line(s)",
+			commands: 2,
+		},
+	}
+	for _, tt := range tests {
+		var v *View
+		h, err := NewHarness(20, 10, testLayout(viewSpec{"a", 0, 0, 19, 9, func(nv *View) {
+			v = nv
+			v.Editable = true
+			fmt.Fprint(v, "abc\ndef\nghi")
+		}}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		v.Overwrite = tt.overwrite
+		v.AbsMoveCursor(tt.x, tt.y, false)
+		for _, step := range tt.steps {
+			step(v)
+			if err := h.Draw(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if got := v.Buffer(); got != tt.buffer {
+			t.Errorf("%s: buffer %q, want %q", tt.name, got, tt.buffer)
+		}
+		if got := v.Actions.cur.cmd.Info(); got != tt.info {
+			t.Errorf("%s: info %q, want %q", tt.name, got, tt.info)
+		}
+		if got := v.Actions.Usage().Commands; got != tt.commands {
+			t.Errorf("%s: %d commands, want %d", tt.name, got, tt.commands)
+		}
+		for i := 0; i < tt.commands; i++ {
+			v.Actions.Undo()
+		}
+		if got := v.Buffer(); got != "abc\ndef\nghi\n" {
+			t.Errorf("%s: buffer %q after undo", tt.name, got)
+		}
+		if cx, cy := v.Cursor(); cx != tt.cx || cy != tt.cy {
+			t.Errorf("%s: cursor (%d, %d) after undo, want (%d, %d)", tt.name, cx, cy, tt.cx, tt.cy)
+		}
+		v.Actions.Redo()
+		if tt.commands == 1 && v.Buffer() != tt.buffer {
+			t.Errorf("%s: buffer %q after redo, want %q", tt.name, v.Buffer(), tt.buffer)
+		}
+		h.Close()
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// groupView returns a view holding "abc\ndef", without any command.
+func groupView() *View {
+	v := newView("v", 0, 0, 20, 10)
+	fmt.Fprint(v, "abc\ndef")
+	return v
+}
+
+// replace replaces the text from (x0, y0) to (x1, y1) of v with s.
+func replace(t *testing.T, v *View, x0, y0, x1, y1 int, s string) {
+	if err := v.ReplaceRange(x0, y0, x1, y1, s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUndoGroup(t *testing.T) {
+	v := groupView()
+	v.Actions.BeginGroup("rename")
+	replace(t, v, 0, 0, 1, 0, "x")
+	replace(t, v, 1, 1, 2, 1, "yy")
+	replace(t, v, 3, 0, 3, 0, "\nz")
+	v.Actions.EndGroup()
+
+	const edited = "xbc\nz\ndyyf\n"
+	if got := v.Buffer(); got != edited {
+		t.Errorf("buffer %q, want %q", got, edited)
+	}
+	if got := v.Actions.Usage().Commands; got != 1 {
+		t.Errorf("%d commands, want 1", got)
+	}
+	if got := v.Actions.cur.cmd.Info(); got != "rename" {
+		t.Errorf("info %q, want %q", got, "rename")
+	}
+	v.Actions.Undo()
+	if got := v.Buffer(); got != "abc\ndef\n" {
+		t.Errorf("buffer %q after undo", got)
+	}
+	v.Actions.Redo()
+	if got := v.Buffer(); got != edited {
+		t.Errorf("buffer %q after redo, want %q", got, edited)
+	}
+
+	// the command after the group is not merged with it
+	replace(t, v, 0, 0, 0, 0, "w")
+	if got := v.Actions.Usage().Commands; got != 2 {
+		t.Errorf("%d commands after the group, want 2", got)
+	}
+
+	// an empty group adds no command, an unmatched EndGroup is ignored
+	v.Actions.BeginGroup("empty")
+	v.Actions.EndGroup()
+	v.Actions.EndGroup()
+	if got := v.Actions.Usage().Commands; got != 2 {
+		t.Errorf("%d commands after an empty group, want 2", got)
+	}
+}
+
+func TestUndoGroupNested(t *testing.T) {
+	v := groupView()
+	v.Actions.BeginGroup("outer")
+	replace(t, v, 0, 0, 0, 0, "1")
+	v.Actions.BeginGroup("inner")
+	replace(t, v, 1, 0, 1, 0, "2")
+	v.Actions.EndGroup()
+	// the outer group is still open
+	if got := v.Actions.Usage().Commands; got != 0 {
+		t.Errorf("%d commands after the inner group, want 0", got)
+	}
+	replace(t, v, 2, 0, 2, 0, "3")
+	v.Actions.EndGroup()
+
+	if got := v.Buffer(); got != "123abc\ndef\n" {
+		t.Errorf("buffer %q", got)
+	}
+	if got := v.Actions.Usage().Commands; got != 1 {
+		t.Errorf("%d commands, want 1", got)
+	}
+	if got := v.Actions.cur.cmd.Info(); got != "outer" {
+		t.Errorf("info %q, want %q", got, "outer")
+	}
+	v.Actions.Undo()
+	if got := v.Buffer(); got != "abc\ndef\n" {
+		t.Errorf("buffer %q after undo", got)
+	}
+
+	// Undo ends the groups left open
+	v.Actions.BeginGroup("outer")
+	v.Actions.BeginGroup("inner")
+	replace(t, v, 0, 1, 0, 1, "x")
+	v.Actions.Undo()
+	if got := v.Buffer(); got != "abc\ndef\n" {
+		t.Errorf("buffer %q after undoing open groups", got)
+	}
+	if v.Actions.group != nil || v.Actions.groupDepth != 0 {
+		t.Errorf("groups still open after undo")
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	errFail := errors.New("fail")
+	v := groupView()
+
+	err := v.Actions.Transaction("fail", func() error {
+		replace(t, v, 0, 0, 1, 0, "x")
+		replace(t, v, 0, 1, 0, 1, "\n")
+		return errFail
+	})
+	if err != errFail {
+		t.Errorf("error %v, want %v", err, errFail)
+	}
+	if got := v.Buffer(); got != "abc\ndef\n" {
+		t.Errorf("buffer %q after a failed transaction", got)
+	}
+	if got := v.Actions.Usage().Commands; got != 0 {
+		t.Errorf("%d commands after a failed transaction, want 0", got)
+	}
+
+	// a failed inner transaction only rolls back its own commands
+	err = v.Actions.Transaction("outer", func() error {
+		replace(t, v, 0, 0, 0, 0, "1")
+		if err := v.Actions.Transaction("inner", func() error {
+			replace(t, v, 1, 0, 1, 0, "2")
+			return errFail
+		}); err != errFail {
+			t.Errorf("inner error %v, want %v", err, errFail)
+		}
+		replace(t, v, 1, 0, 1, 0, "3")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.Buffer(); got != "13abc\ndef\n" {
+		t.Errorf("buffer %q", got)
+	}
+	if got := v.Actions.Usage().Commands; got != 1 {
+		t.Errorf("%d commands, want 1", got)
+	}
+	v.Actions.Undo()
+	if got := v.Buffer(); got != "abc\ndef\n" {
+		t.Errorf("buffer %q after undo", got)
+	}
+}
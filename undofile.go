@@ -0,0 +1,322 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+// historyVersion is the version of the format of the history files.
+const historyVersion = 2
+
+// ErrHistoryMismatch is returned by View.LoadHistory when the buffer of
+// the view is not the one the history has been saved with, usually
+// because the file has been modified by another program.
+var ErrHistoryMismatch = errors.New("undo history does not match the buffer")
+
+// historyFile is the content of a history file. The states of the undo
+// tree are stored by sequence number, the initial state first.
+type historyFile struct {
+	Version int
+	Hash    string // SHA-256 of the buffer when the history was saved
+	Current int    // sequence number of the current state
+	Nodes   []historyNode
+}
+
+// historyNode is a state of the undo tree in a history file.
+type historyNode struct {
+	Parent int        `json:",omitempty"`
+	Next   int        `json:",omitempty"`
+	Time   time.Time  // time of the last change of Cmd
+	Cmd    *cmdRecord `json:",omitempty"` // nil for the initial state
+}
+
+// cmdRecord is a command in a history file. Type is the name of the type
+// of the command, the other fields are set depending on it.
+type cmdRecord struct {
+	Type   string
+	X, Y   int           `json:",omitempty"`
+	X0, Y0 int           `json:",omitempty"`
+	Old    []cellsRecord `json:",omitempty"`
+	New    []cellsRecord `json:",omitempty"`
+	AX, AY int           `json:",omitempty"`
+	Name   string        `json:",omitempty"`
+	Cmds   []cmdRecord   `json:",omitempty"`
+}
+
+// cellsRecord is a sequence of cells in a history file. Only the styled
+// cells have an entry in Styles.
+type cellsRecord struct {
+	Text   string
+	Styles []styleRecord `json:",omitempty"`
+}
+
+// styleRecord holds the colors of the styled cell at the index I.
+type styleRecord struct {
+	I      int
+	Fg, Bg Attribute
+}
+
+// HistoryPath returns the path of the file holding the undo history of
+// the file at path: a hidden file in the same directory.
+func HistoryPath(path string) string {
+	dir, name := filepath.Split(path)
+	return filepath.Join(dir, "."+name+".undo")
+}
+
+// SaveHistory saves the undo history of the view next to the file at
+// path, which should hold the content of the buffer, see HistoryPath. The
+// groups of commands which are still open are ended first. Only the
+// commands of this package can be saved.
+func (v *View) SaveHistory(path string) error {
+	con := &v.Actions
+	con.endGroups()
+	con.init()
+	h := historyFile{
+		Version: historyVersion,
+		Hash:    bufferHash(v),
+		Current: con.index(),
+	}
+	// the states are renumbered, since the oldest ones may have been removed
+	index := make(map[*undoNode]int, len(con.nodes))
+	for i, n := range con.nodes {
+		index[n] = i
+		hn := historyNode{Next: n.next, Time: n.time}
+		if n.parent != nil {
+			r, err := encodeCommand(n.cmd)
+			if err != nil {
+				return err
+			}
+			hn.Parent, hn.Cmd = index[n.parent], &r
+		}
+		h.Nodes = append(h.Nodes, hn)
+	}
+	b, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(HistoryPath(path), b, 0644)
+}
+
+// LoadHistory restores the undo history of the view saved by SaveHistory
+// for the file at path. The buffer must have been loaded from the file
+// beforehand: if it is not the one the history has been saved with,
+// ErrHistoryMismatch is returned. The history of the view is left
+// unchanged on error.
+func (v *View) LoadHistory(path string) error {
+	b, err := ioutil.ReadFile(HistoryPath(path))
+	if err != nil {
+		return err
+	}
+	var h historyFile
+	if err := json.Unmarshal(b, &h); err != nil {
+		return err
+	}
+	if h.Version != historyVersion {
+		return fmt.Errorf("unsupported undo history version %d", h.Version)
+	}
+	if h.Hash != bufferHash(v) {
+		return ErrHistoryMismatch
+	}
+	if len(h.Nodes) == 0 || h.Current < 0 || h.Current >= len(h.Nodes) {
+		return errors.New("invalid undo history")
+	}
+
+	nodes := make([]*undoNode, len(h.Nodes))
+	for i, hn := range h.Nodes {
+		n := &undoNode{seq: i, time: hn.Time}
+		if i > 0 {
+			if hn.Cmd == nil || hn.Parent < 0 || hn.Parent >= i {
+				return errors.New("invalid undo history")
+			}
+			if n.cmd, err = decodeCommand(v, *hn.Cmd); err != nil {
+				return err
+			}
+			n.parent = nodes[hn.Parent]
+			n.branch = len(n.parent.children)
+			n.parent.children = append(n.parent.children, n)
+		}
+		nodes[i] = n
+	}
+	for i, hn := range h.Nodes {
+		if hn.Next < 0 || hn.Next > 0 && hn.Next >= len(nodes[i].children) {
+			return errors.New("invalid undo history")
+		}
+		nodes[i].next = hn.Next
+	}
+	if !checkHistory(v, nodes[0], nodes[h.Current]) {
+		return errors.New("invalid undo history")
+	}
+
+	con := &v.Actions
+	con.endGroups()
+	con.root, con.cur, con.nodes = nodes[0], nodes[h.Current], nodes
+	con.merge = false
+	con.bytes = 0
+	for _, n := range nodes {
+		n.size = nodeOverhead + commandSize(n.cmd)
+		con.bytes += n.size
+	}
+	con.trim()
+	return nil
+}
+
+// checkHistory reports whether the commands of the tree from root can be
+// undone and redone, cur being the state of the buffer of v: the ranges
+// they replace must be in the buffer. The commands are replayed on a copy
+// of the buffer, from cur to root, then to every state.
+func checkHistory(v *View, root, cur *undoNode) bool {
+	b := &View{lines: append([][]cell(nil), v.lines...)}
+	for n := cur; n != root; n = n.parent {
+		if !replayCommand(b, n.cmd, true) {
+			return false
+		}
+	}
+	return checkSubtree(b, root)
+}
+
+// checkSubtree reports whether the commands of the descendants of n can be
+// replayed, b holding the buffer of the state n.
+func checkSubtree(b *View, n *undoNode) bool {
+	for _, c := range n.children {
+		if !replayCommand(b, c.cmd, false) || !checkSubtree(b, c) || !replayCommand(b, c.cmd, true) {
+			return false
+		}
+	}
+	return true
+}
+
+// replayCommand executes c on the buffer of b, or reverses it if reversed
+// is true. It returns false if a range replaced by c is not in the buffer.
+func replayCommand(b *View, c Command, reversed bool) bool {
+	switch c := c.(type) {
+	case *GroupCmd:
+		for k := range c.cmds {
+			i := k
+			if reversed {
+				i = len(c.cmds) - 1 - k
+			}
+			if !replayCommand(b, c.cmds[i], reversed) {
+				return false
+			}
+		}
+		return true
+	case *ReplaceRangeCmd:
+		from, to := c.old, c.new
+		if reversed {
+			from, to = to, from
+		}
+		x1, y1 := textEnd(c.x0, c.y0, from)
+		if !validRange(b.lines, c.x0, c.y0, x1, y1) {
+			return false
+		}
+		b.replaceText(c.x0, c.y0, x1, y1, to)
+		return true
+	}
+	return false
+}
+
+// validRange reports whether the range from (x0, y0) to (x1, y1) is in
+// lines. An empty range at the start of an empty buffer is valid.
+func validRange(lines [][]cell, x0, y0, x1, y1 int) bool {
+	if len(lines) == 0 {
+		return x0 == 0 && y0 == 0 && x1 == 0 && y1 == 0
+	}
+	return y0 >= 0 && y0 <= y1 && y1 < len(lines) &&
+		x0 >= 0 && x0 <= len(lines[y0]) && x1 >= 0 && x1 <= len(lines[y1]) &&
+		(y0 < y1 || x0 <= x1)
+}
+
+// bufferHash returns the SHA-256 of the buffer of v.
+func bufferHash(v *View) string {
+	sum := sha256.Sum256([]byte(v.Buffer()))
+	return hex.EncodeToString(sum[:])
+}
+
+// encodeCommand returns the record of c in a history file.
+func encodeCommand(c Command) (cmdRecord, error) {
+	var r cmdRecord
+	switch c := c.(type) {
+	case *GroupCmd:
+		r = cmdRecord{Type: "Group", Name: c.name}
+		for _, gc := range c.cmds {
+			gr, err := encodeCommand(gc)
+			if err != nil {
+				return r, err
+			}
+			r.Cmds = append(r.Cmds, gr)
+		}
+	case *ReplaceRangeCmd:
+		r = cmdRecord{Type: "ReplaceRange", X: c.x, Y: c.y, X0: c.x0, Y0: c.y0, Old: encodeLines(c.old), New: encodeLines(c.new), AX: c.ax, AY: c.ay}
+	default:
+		return r, fmt.Errorf("command %T cannot be saved", c)
+	}
+	return r, nil
+}
+
+// decodeCommand returns the command of the record r, performed on v.
+func decodeCommand(v *View, r cmdRecord) (Command, error) {
+	switch r.Type {
+	case "Group":
+		g := &GroupCmd{name: r.Name}
+		for _, gr := range r.Cmds {
+			c, err := decodeCommand(v, gr)
+			if err != nil {
+				return nil, err
+			}
+			g.cmds.Push(c)
+		}
+		return g, nil
+	case "ReplaceRange":
+		if len(r.Old) == 0 || len(r.New) == 0 {
+			break
+		}
+		return &ReplaceRangeCmd{v: v, x: r.X, y: r.Y, x0: r.X0, y0: r.Y0, old: decodeLines(r.Old), new: decodeLines(r.New), ax: r.AX, ay: r.AY}, nil
+	default:
+		return nil, fmt.Errorf("unknown command %q in undo history", r.Type)
+	}
+	return nil, fmt.Errorf("invalid command %q in undo history", r.Type)
+}
+
+// encodeLines returns the records of lines.
+func encodeLines(lines [][]cell) []cellsRecord {
+	rs := make([]cellsRecord, len(lines))
+	for i, l := range lines {
+		rs[i].Text = cellsString(l)
+		for j, c := range l {
+			if c.styled {
+				rs[i].Styles = append(rs[i].Styles, styleRecord{I: j, Fg: c.fgColor, Bg: c.bgColor})
+			}
+		}
+	}
+	return rs
+}
+
+// decodeLines returns the lines of the records rs.
+func decodeLines(rs []cellsRecord) [][]cell {
+	lines := make([][]cell, len(rs))
+	for i, r := range rs {
+		lines[i] = decodeCells(r)
+	}
+	return lines
+}
+
+// decodeCells returns the cells of the record r.
+func decodeCells(r cellsRecord) []cell {
+	cs := stringCells(r.Text)
+	for _, s := range r.Styles {
+		if s.I >= 0 && s.I < len(cs) {
+			cs[s.I] = cell{chr: cs[s.I].chr, fgColor: s.Fg, bgColor: s.Bg, styled: true}
+		}
+	}
+	return cs
+}
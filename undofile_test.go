@@ -0,0 +1,130 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// historyDir returns a temporary directory and the path of an edited file
+// in it.
+func historyDir(t *testing.T) (string, string) {
+	dir, err := ioutil.TempDir("", "gocui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, filepath.Join(dir, "file.txt")
+}
+
+// loadedView returns a view holding text whose history is loaded from the
+// file at path.
+func loadedView(t *testing.T, text, path string) *View {
+	v := newView("v", 0, 0, 20, 10)
+	fmt.Fprint(v, text)
+	if err := v.LoadHistory(path); err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestHistoryRoundTrip(t *testing.T) {
+	dir, path := historyDir(t)
+	defer os.RemoveAll(dir)
+
+	v := twoBranches(t)
+	if err := v.SaveHistory(path); err != nil {
+		t.Fatal(err)
+	}
+	lv := loadedView(t, "aXY", path)
+	// the times are compared in UTC, their location is not saved
+	got, want := lv.Actions.Branches(), v.Actions.Branches()
+	for i := range got {
+		got[i].Time = got[i].Time.UTC()
+	}
+	for i := range want {
+		want[i].Time = want[i].Time.UTC()
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("branches %+v, want %+v", got, want)
+	}
+	if got := lv.Actions.Usage(); got != v.Actions.Usage() {
+		t.Errorf("usage %+v, want %+v", got, v.Actions.Usage())
+	}
+	for _, s := range []struct {
+		name   string
+		op     func(con *Context)
+		buffer string
+	}{
+		{"Undo", func(con *Context) { con.Undo() }, "aX\n"},
+		{"GoTo 3", func(con *Context) { con.GoTo(3) }, "abc\n"},
+		{"Undo", func(con *Context) { con.Undo(); con.Undo(); con.Undo() }, "\n"},
+		{"Redo", func(con *Context) { con.Redo() }, "a\n"},
+	} {
+		s.op(&lv.Actions)
+		if got := lv.Buffer(); got != s.buffer {
+			t.Errorf("%s: buffer %q, want %q", s.name, got, s.buffer)
+		}
+	}
+
+	if err := newView("v", 0, 0, 20, 10).LoadHistory(path); err != ErrHistoryMismatch {
+		t.Errorf("LoadHistory on another buffer: error %v, want %v", err, ErrHistoryMismatch)
+	}
+}
+
+func TestHistoryCorrupt(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(h *historyFile)
+	}{
+		{"column after the line", func(h *historyFile) { h.Nodes[3].Cmd.X0 = 10 }},
+		{"negative column", func(h *historyFile) { h.Nodes[1].Cmd.X0 = -1 }},
+		{"line after the buffer", func(h *historyFile) { h.Nodes[5].Cmd.Y0 = 2 }},
+		{"replaced text too long", func(h *historyFile) {
+			h.Nodes[2].Cmd.Old = []cellsRecord{{Text: "xyz"}}
+		}},
+		{"command of a group", func(h *historyFile) {
+			c := *h.Nodes[4].Cmd
+			c.Y0 = 3
+			h.Nodes[4].Cmd = &cmdRecord{Type: "Group", Cmds: []cmdRecord{c}}
+		}},
+	}
+	for _, tt := range tests {
+		dir, path := historyDir(t)
+		if err := twoBranches(t).SaveHistory(path); err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadFile(HistoryPath(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var h historyFile
+		if err := json.Unmarshal(b, &h); err != nil {
+			t.Fatal(err)
+		}
+		tt.corrupt(&h)
+		if b, err = json.Marshal(h); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(HistoryPath(path), b, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		v := newView("v", 0, 0, 20, 10)
+		fmt.Fprint(v, "aXY")
+		if err := v.LoadHistory(path); err == nil || err.Error() != "invalid undo history" {
+			t.Errorf("%s: error %v", tt.name, err)
+		}
+		if got := v.Actions.Usage(); got != (UndoUsage{}) {
+			t.Errorf("%s: history loaded, usage %+v", tt.name, got)
+		}
+		os.RemoveAll(dir)
+	}
+}
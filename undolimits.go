@@ -0,0 +1,206 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import "time"
+
+// Estimated sizes, in bytes, of the structures of the undo tree.
+const (
+	nodeOverhead    = 96 // undoNode, without its command
+	commandOverhead = 48 // command, without its text
+	sliceSize       = 24 // slice header
+	cellSize        = 24
+	runeSize        = 4
+)
+
+// UndoLimits bounds the memory used by the undo history of a Context. A
+// zero field means no limit.
+type UndoLimits struct {
+	// MaxCommands is the maximum number of states of the undo tree,
+	// without the initial state.
+	MaxCommands int
+
+	// MaxBytes is the maximum estimated memory used by the undo tree.
+	MaxBytes int
+
+	// MaxAge is the maximum age of the oldest command.
+	MaxAge time.Duration
+
+	// If Compact is true, the oldest successive commands are grouped in a
+	// single state when MaxCommands is exceeded, instead of being
+	// removed. They can still be undone, but only together.
+	Compact bool
+}
+
+// UndoUsage describes the memory used by the undo history of a Context.
+type UndoUsage struct {
+	Commands int // number of states, without the initial state
+	Bytes    int // estimated memory used
+}
+
+// SetLimits sets the limits of the undo history, removing the oldest
+// states if they are exceeded. The oldest command executed from the
+// initial state is removed with the branch it starts, unless it leads to
+// the current state, in which case it cannot be undone anymore and the
+// other branches are removed.
+func (con *Context) SetLimits(l UndoLimits) {
+	con.limits = l
+	con.trim()
+}
+
+// Limits returns the limits of the undo history.
+func (con *Context) Limits() UndoLimits {
+	return con.limits
+}
+
+// Usage returns the memory used by the undo history, e.g. for a status
+// bar.
+func (con *Context) Usage() UndoUsage {
+	if con.root == nil {
+		return UndoUsage{}
+	}
+	return UndoUsage{Commands: len(con.nodes) - 1, Bytes: con.bytes}
+}
+
+// trim removes or compacts the oldest states until the limits are
+// respected.
+func (con *Context) trim() {
+	l := con.limits
+	for con.root != nil && len(con.root.children) > 0 {
+		var ok bool
+		switch {
+		case l.MaxCommands > 0 && len(con.nodes)-1 > l.MaxCommands:
+			ok = l.Compact && con.compactOldest() || con.evictOldest()
+		case l.MaxBytes > 0 && con.bytes > l.MaxBytes:
+			ok = con.evictOldest()
+		case l.MaxAge > 0 && con.clock().Sub(con.root.children[0].time) > l.MaxAge:
+			ok = con.evictOldest()
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// evictOldest removes the oldest command executed from the initial state,
+// see SetLimits. It returns false if there is none.
+func (con *Context) evictOldest() bool {
+	if con.root == nil || len(con.root.children) == 0 {
+		return false
+	}
+	root, n := con.root, con.root.children[0]
+	onPath := false
+	for p := con.cur; p != nil; p = p.parent {
+		if p == n {
+			onPath = true
+			break
+		}
+	}
+
+	removed := make(map[*undoNode]bool)
+	if onPath {
+		// n becomes the initial state
+		removed[root] = true
+		for _, c := range root.children[1:] {
+			markSubtree(c, removed)
+		}
+		n.cmd, n.parent, n.branch = nil, nil, 0
+		con.bytes -= n.size - nodeOverhead
+		n.size = nodeOverhead
+		con.root = n
+	} else {
+		markSubtree(n, removed)
+		root.children = root.children[1:]
+		for i, c := range root.children {
+			c.branch = i
+		}
+		if root.next > 0 {
+			root.next--
+		}
+	}
+
+	nodes := con.nodes[:0]
+	for _, m := range con.nodes {
+		if removed[m] {
+			con.bytes -= m.size
+		} else {
+			nodes = append(nodes, m)
+		}
+	}
+	for i := len(nodes); i < len(con.nodes); i++ {
+		con.nodes[i] = nil
+	}
+	con.nodes = nodes
+	return true
+}
+
+// markSubtree adds n and its descendants to removed.
+func markSubtree(n *undoNode, removed map[*undoNode]bool) {
+	removed[n] = true
+	for _, c := range n.children {
+		markSubtree(c, removed)
+	}
+}
+
+// compactOldest groups the oldest command having a single following
+// command with it, unless it leads to the current state. It returns false
+// if there is no such command.
+func (con *Context) compactOldest() bool {
+	for i, p := range con.nodes {
+		if p == con.root || p == con.cur || len(p.children) != 1 {
+			continue
+		}
+		n := p.children[0]
+		g, ok := p.cmd.(*GroupCmd)
+		if !ok || g.name != "" {
+			g = &GroupCmd{cmds: CmdStack{p.cmd}}
+		}
+		g.cmds.Push(n.cmd)
+		p.cmd, p.children, p.next, p.time = g, n.children, n.next, n.time
+		for _, c := range p.children {
+			c.parent = p
+		}
+		if con.cur == n {
+			con.cur = p
+		}
+
+		con.bytes -= p.size + n.size
+		p.size = nodeOverhead + commandSize(g)
+		con.bytes += p.size
+		j := i + 1
+		for con.nodes[j] != n {
+			j++
+		}
+		con.nodes = append(con.nodes[:j], con.nodes[j+1:]...)
+		return true
+	}
+	return false
+}
+
+// commandSize returns the estimated memory used by c.
+func commandSize(c Command) int {
+	switch c := c.(type) {
+	case nil:
+		return 0
+	case *GroupCmd:
+		size := commandOverhead
+		for _, gc := range c.cmds {
+			size += commandSize(gc)
+		}
+		return size
+	case *ReplaceRangeCmd:
+		return commandOverhead + linesSize(c.old) + linesSize(c.new) + (len(c.signs)+len(c.newSigns))*sliceSize
+	}
+	return commandOverhead
+}
+
+// linesSize returns the estimated memory used by lines.
+func linesSize(lines [][]cell) int {
+	size := 0
+	for _, l := range lines {
+		size += sliceSize + len(l)*cellSize
+	}
+	return size
+}
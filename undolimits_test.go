@@ -0,0 +1,148 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// letterSize is the estimated memory used by a state appending a letter.
+const letterSize = nodeOverhead + commandOverhead + sliceSize + sliceSize + cellSize
+
+// undoAll undoes the commands until the oldest state and returns the
+// buffers of the states reached, the current one first.
+func undoAll(v *View) []string {
+	bufs := []string{v.Buffer()}
+	for i := 0; i < 100 && v.Actions.cur != v.Actions.root; i++ {
+		v.Actions.Undo()
+		bufs = append(bufs, v.Buffer())
+	}
+	return bufs
+}
+
+func TestUndoLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits UndoLimits
+		now    time.Duration // time of the fake clock when setting the limits
+		usage  UndoUsage
+		undos  []string
+	}{
+		{
+			name:  "no limit",
+			usage: UndoUsage{Commands: 4, Bytes: nodeOverhead + 4*letterSize},
+			undos: []string{"abcd\n", "abc\n", "ab\n", "a\n", "\n"},
+		},
+		{
+			name:   "max commands",
+			limits: UndoLimits{MaxCommands: 2},
+			usage:  UndoUsage{Commands: 2, Bytes: nodeOverhead + 2*letterSize},
+			undos:  []string{"abcd\n", "abc\n", "ab\n"},
+		},
+		{
+			name:   "max commands compacted",
+			limits: UndoLimits{MaxCommands: 2, Compact: true},
+			usage:  UndoUsage{Commands: 2, Bytes: nodeOverhead + letterSize + nodeOverhead + commandOverhead + 3*(letterSize-nodeOverhead)},
+			undos:  []string{"abcd\n", "abc\n", "\n"},
+		},
+		{
+			name:   "max bytes",
+			limits: UndoLimits{MaxBytes: nodeOverhead + 3*letterSize - 1},
+			usage:  UndoUsage{Commands: 2, Bytes: nodeOverhead + 2*letterSize},
+			undos:  []string{"abcd\n", "abc\n", "ab\n"},
+		},
+		{
+			name:   "max age",
+			limits: UndoLimits{MaxAge: 7 * time.Second},
+			now:    10 * time.Second,
+			usage:  UndoUsage{Commands: 2, Bytes: nodeOverhead + 2*letterSize},
+			undos:  []string{"abcd\n", "abc\n", "ab\n"},
+		},
+	}
+	for _, tt := range tests {
+		clock := &fakeClock{t: at(0)}
+		v := newView("v", 0, 0, 20, 10)
+		v.Actions.now = clock.now
+		if got := v.Actions.Usage(); got != (UndoUsage{}) {
+			t.Errorf("%s: initial usage %+v", tt.name, got)
+		}
+		for i, s := range []string{"a", "b", "c", "d"} {
+			clock.t = at(time.Duration(i+1) * time.Second)
+			appendText(t, v, s)
+		}
+		if tt.now > 0 {
+			clock.t = at(tt.now)
+		}
+		v.Actions.SetLimits(tt.limits)
+		if got := v.Actions.Limits(); got != tt.limits {
+			t.Errorf("%s: limits %+v", tt.name, got)
+		}
+		if got := v.Actions.Usage(); got != tt.usage {
+			t.Errorf("%s: usage %+v, want %+v", tt.name, got, tt.usage)
+		}
+		if got := undoAll(v); !reflect.DeepEqual(got, tt.undos) {
+			t.Errorf("%s: undos %q, want %q", tt.name, got, tt.undos)
+		}
+	}
+}
+
+func TestUndoLimitsOnPush(t *testing.T) {
+	v := newView("v", 0, 0, 20, 10)
+	v.Actions.SetLimits(UndoLimits{MaxCommands: 2})
+	for _, s := range []string{"a", "b", "c", "d"} {
+		appendText(t, v, s)
+		if got := v.Actions.Usage().Commands; got > 2 {
+			t.Fatalf("%d commands after %q", got, s)
+		}
+	}
+	if got, want := undoAll(v), []string{"abcd\n", "abc\n", "ab\n"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("undos %q, want %q", got, want)
+	}
+	v.Actions.Redo()
+	v.Actions.Redo()
+	if got := v.Buffer(); got != "abcd\n" {
+		t.Errorf("buffer %q after redo", got)
+	}
+}
+
+func TestUndoLimitsBranches(t *testing.T) {
+	// the oldest branch, which does not lead to the current state, is
+	// removed entirely
+	v := newView("v", 0, 0, 20, 10)
+	appendText(t, v, "a")
+	v.Actions.Undo()
+	appendText(t, v, "X")
+	appendText(t, v, "Y")
+	v.Actions.SetLimits(UndoLimits{MaxCommands: 2})
+	if got := v.Actions.Usage().Commands; got != 2 {
+		t.Errorf("%d commands, want 2", got)
+	}
+	bs := v.Actions.Branches()
+	if len(bs) != 1 || bs[0].Info != "Write : Y" {
+		t.Errorf("branches %+v", bs)
+	}
+	if got, want := undoAll(v), []string{"XY\n", "X\n", "\n"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("undos %q, want %q", got, want)
+	}
+
+	// the oldest command leads to the current state, it cannot be undone
+	// anymore and the other branch is removed
+	v = twoBranches(t)
+	v.Actions.SetLimits(UndoLimits{MaxCommands: 4})
+	bs = v.Actions.Branches()
+	if len(bs) != 2 {
+		t.Errorf("branches %+v", bs)
+	}
+	v.Actions.SetLimits(UndoLimits{MaxCommands: 2})
+	bs = v.Actions.Branches()
+	if len(bs) != 1 || bs[0].Seq != 5 || bs[0].Length != 2 {
+		t.Errorf("branches %+v", bs)
+	}
+	if got, want := undoAll(v), []string{"aXY\n", "aX\n", "a\n"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("undos %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// undoNode is a state of the undo tree, reached by executing cmd from the
+// state parent. The initial state has no command.
+type undoNode struct {
+	cmd      Command
+	parent   *undoNode
+	children []*undoNode
+	branch   int       // index of the node in the children of its parent
+	next     int       // index of the child reached by Redo
+	seq      int       // sequence number, the order of creation of the states
+	time     time.Time // time of the last change of cmd
+	size     int       // estimated memory used by the node, see commandSize
+}
+
+// Branch describes a branch of the undo tree, going from the initial
+// state to a state which has not been followed by any command.
+type Branch struct {
+	Seq     int       // sequence number of the last state of the branch
+	Info    string    // Info of the last command of the branch
+	Time    time.Time // time of the last command of the branch
+	Length  int       // number of commands of the branch
+	Current bool      // Redo follows this branch from the current state
+}
+
+// init creates the initial state.
+func (con *Context) init() {
+	if con.root == nil {
+		con.root = &undoNode{time: con.clock(), size: nodeOverhead}
+		con.cur = con.root
+		con.nodes = []*undoNode{con.root}
+		con.bytes = con.root.size
+	}
+}
+
+// clock returns the current time.
+func (con *Context) clock() time.Time {
+	if con.now != nil {
+		return con.now()
+	}
+	return time.Now()
+}
+
+// push adds a state reached from the current one by c, and moves to it.
+// The oldest states are removed if the limits are exceeded.
+func (con *Context) push(c Command) {
+	p := con.cur
+	n := &undoNode{
+		cmd:    c,
+		parent: p,
+		branch: len(p.children),
+		seq:    con.nodes[len(con.nodes)-1].seq + 1,
+		time:   con.clock(),
+		size:   nodeOverhead + commandSize(c),
+	}
+	p.children = append(p.children, n)
+	p.next = n.branch
+	con.nodes = append(con.nodes, n)
+	con.bytes += n.size
+	con.cur = n
+	con.trim()
+}
+
+// node returns the state with the given sequence number, nil if there is
+// none.
+func (con *Context) node(seq int) *undoNode {
+	i := sort.Search(len(con.nodes), func(i int) bool { return con.nodes[i].seq >= seq })
+	if i == len(con.nodes) || con.nodes[i].seq != seq {
+		return nil
+	}
+	return con.nodes[i]
+}
+
+// index returns the index of the current state in con.nodes.
+func (con *Context) index() int {
+	return sort.Search(len(con.nodes), func(i int) bool { return con.nodes[i].seq >= con.cur.seq })
+}
+
+// State returns the sequence number of the current state, 0 for the
+// initial state unless the oldest states have been removed, see SetLimits.
+// The sequence numbers follow the order in which the commands have been
+// executed, whatever their branch.
+func (con *Context) State() int {
+	if con.cur == nil {
+		return 0
+	}
+	return con.cur.seq
+}
+
+// GoTo moves to the state with the given sequence number, undoing the
+// commands up to the state shared with the current branch, then redoing
+// the commands of the branch of the target state.
+func (con *Context) GoTo(seq int) error {
+	con.endGroups()
+	con.init()
+	target := con.node(seq)
+	if target == nil {
+		return errors.New("unknown state")
+	}
+
+	ancestors := make(map[*undoNode]bool)
+	for n := target; n != nil; n = n.parent {
+		ancestors[n] = true
+	}
+	for !ancestors[con.cur] {
+		n := con.cur
+		con.cur = n.parent
+		n.cmd.Reverse()
+	}
+
+	var path []*undoNode
+	for n := target; n != con.cur; n = n.parent {
+		path = append(path, n)
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		n := path[i]
+		n.parent.next = n.branch
+		con.cur = n
+		n.cmd.Execute()
+	}
+	con.merge = false
+	return nil
+}
+
+// Earlier moves n states back in time, following the order of execution
+// of the commands instead of the current branch.
+func (con *Context) Earlier(n int) error {
+	con.init()
+	i := con.index() - n
+	if i < 0 {
+		i = 0
+	}
+	return con.GoTo(con.nodes[i].seq)
+}
+
+// Later moves n states forward in time, following the order of execution
+// of the commands instead of the current branch.
+func (con *Context) Later(n int) error {
+	con.init()
+	i := con.index() + n
+	if i >= len(con.nodes) {
+		i = len(con.nodes) - 1
+	}
+	return con.GoTo(con.nodes[i].seq)
+}
+
+// EarlierBy moves to the last state reached d before the current one.
+func (con *Context) EarlierBy(d time.Duration) error {
+	con.init()
+	return con.goToTime(con.cur.time.Add(-d))
+}
+
+// LaterBy moves to the last state reached d after the current one.
+func (con *Context) LaterBy(d time.Duration) error {
+	con.init()
+	return con.goToTime(con.cur.time.Add(d))
+}
+
+// goToTime moves to the last state reached at t, or to the initial state.
+func (con *Context) goToTime(t time.Time) error {
+	seq := con.root.seq
+	for _, n := range con.nodes {
+		if !n.time.After(t) {
+			seq = n.seq
+		}
+	}
+	return con.GoTo(seq)
+}
+
+// Branches returns the branches of the undo tree, in the order of their
+// last commands.
+func (con *Context) Branches() []Branch {
+	if con.root == nil {
+		return nil
+	}
+	current := con.cur
+	for len(current.children) > 0 {
+		current = current.children[current.next]
+	}
+
+	var bs []Branch
+	for _, n := range con.nodes {
+		if len(n.children) > 0 || n == con.root {
+			continue
+		}
+		b := Branch{Seq: n.seq, Info: n.cmd.Info(), Time: n.time, Current: n == current}
+		for p := n; p != con.root; p = p.parent {
+			b.Length++
+		}
+		bs = append(bs, b)
+	}
+	return bs
+}
+
+// SwitchBranch changes the branch followed by Redo from the current state,
+// moving delta branches forward or backward among the commands executed
+// from this state. It returns false if there is a single branch.
+func (con *Context) SwitchBranch(delta int) bool {
+	if con.cur == nil || len(con.cur.children) < 2 {
+		return false
+	}
+	k := len(con.cur.children)
+	con.cur.next = ((con.cur.next+delta)%k + k) % k
+	return true
+}
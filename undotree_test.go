@@ -0,0 +1,130 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock moved forward by the tests.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+// at returns the time d after the start of the fake clocks.
+func at(d time.Duration) time.Time {
+	return time.Unix(1000000, 0).Add(d)
+}
+
+// appendText appends s to the first line of v, as a single command.
+func appendText(t *testing.T, v *View, s string) {
+	x := 0
+	if len(v.lines) > 0 {
+		x = len(v.lines[0])
+	}
+	if err := v.ReplaceRange(x, 0, x, 0, s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// twoBranches returns a view whose undo tree has two branches, "abc" and
+// "aXY", the second one being the current one. The state n is reached n
+// seconds after the initial state.
+func twoBranches(t *testing.T) *View {
+	clock := &fakeClock{t: at(0)}
+	v := newView("v", 0, 0, 20, 10)
+	v.Actions.now = clock.now
+	v.Actions.init()
+	for i, s := range []string{"a", "b", "c", "", "", "X", "Y"} {
+		clock.t = at(time.Duration(i+1) * time.Second)
+		if s == "" {
+			v.Actions.Undo()
+			continue
+		}
+		appendText(t, v, s)
+	}
+	return v
+}
+
+func TestUndoTreeBranches(t *testing.T) {
+	v := twoBranches(t)
+	want := []Branch{
+		{Seq: 3, Info: "Write : c", Time: at(3 * time.Second), Length: 3},
+		{Seq: 5, Info: "Write : Y", Time: at(7 * time.Second), Length: 3, Current: true},
+	}
+	if got := v.Actions.Branches(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if err := v.Actions.GoTo(1); err != nil {
+		t.Fatal(err)
+	}
+	// Redo follows the last branch reached
+	want[0].Current, want[1].Current = false, true
+	if got := v.Actions.Branches(); !reflect.DeepEqual(got, want) {
+		t.Errorf("at 1: got %+v, want %+v", got, want)
+	}
+	if !v.Actions.SwitchBranch(1) {
+		t.Fatal("SwitchBranch returned false")
+	}
+	want[0].Current, want[1].Current = true, false
+	if got := v.Actions.Branches(); !reflect.DeepEqual(got, want) {
+		t.Errorf("after SwitchBranch: got %+v, want %+v", got, want)
+	}
+	v.Actions.Redo()
+	if got := v.Buffer(); got != "ab\n" {
+		t.Errorf("Redo after SwitchBranch: buffer %q", got)
+	}
+	if v.Actions.SwitchBranch(1) {
+		t.Error("SwitchBranch returned true on a single branch")
+	}
+}
+
+func TestUndoTreeNavigation(t *testing.T) {
+	v := twoBranches(t)
+	con := &v.Actions
+	steps := []struct {
+		name   string
+		op     func() error
+		buffer string
+		state  int
+	}{
+		{"start", func() error { return nil }, "aXY\n", 5},
+		{"GoTo 3", func() error { return con.GoTo(3) }, "abc\n", 3},
+		{"Earlier 1", func() error { return con.Earlier(1) }, "ab\n", 2},
+		{"Later 2", func() error { return con.Later(2) }, "aX\n", 4},
+		{"Earlier 10", func() error { return con.Earlier(10) }, "\n", 0},
+		{"Later 10", func() error { return con.Later(10) }, "aXY\n", 5},
+		{"EarlierBy 4s", func() error { return con.EarlierBy(4 * time.Second) }, "abc\n", 3},
+		{"LaterBy 3s", func() error { return con.LaterBy(3 * time.Second) }, "aX\n", 4},
+		{"EarlierBy 1s", func() error { return con.EarlierBy(time.Second) }, "abc\n", 3},
+		{"EarlierBy 1h", func() error { return con.EarlierBy(time.Hour) }, "\n", 0},
+		{"LaterBy 1h", func() error { return con.LaterBy(time.Hour) }, "aXY\n", 5},
+		{"GoTo 1", func() error { return con.GoTo(1) }, "a\n", 1},
+		{"Redo", func() error { con.Redo(); return nil }, "aX\n", 4},
+		{"Undo", func() error { con.Undo(); con.Undo(); return nil }, "\n", 0},
+	}
+	for _, s := range steps {
+		if err := s.op(); err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+		if got := v.Buffer(); got != s.buffer {
+			t.Errorf("%s: buffer %q, want %q", s.name, got, s.buffer)
+		}
+		if got := con.State(); got != s.state {
+			t.Errorf("%s: state %d, want %d", s.name, got, s.state)
+		}
+	}
+
+	if err := con.GoTo(6); err == nil {
+		t.Error("GoTo(6) succeeded")
+	}
+}
@@ -9,8 +9,6 @@ import (
 	"errors"
 	"io"
 	"strings"
-
-	"github.com/nsf/termbox-go"
 )
 
 type geom interface {
@@ -25,6 +23,10 @@ type Container struct {
 	name           string
 	x0, y0, x1, y1 int
 	childrens      []geom
+
+	layout LayoutMode   // how the children are placed
+	items  []layoutItem // children placed by the layout, in order
+	grid   grid         // tracks of a LayoutGrid
 }
 
 // newView returns a new View object.
@@ -107,19 +109,27 @@ func (c *Container) LastView() *View {
 // position.
 type View struct {
 	name           string
+	screen         Screen
+	clipboard      *Clipboard // clipboard of the Gui
 	x0, y0, x1, y1 int
 	ox, oy         int
 	cx, cy         int
-	lines          [][]rune
+	lines          [][]cell
 	readOffset     int
 	readCache      string
 	searchString   string
+	ei             escapeInterpreter // interprets the escape sequences written
 
 	Actions Context
 
 	tainted   bool       // marks if the viewBuffer must be updated
 	viewLines []viewLine // internal representation of the view's buffer
 
+	signs map[int][]Sign // signs of the lines of the internal buffer
+
+	selMode    SelectionMode // mode of the selection, SelectNone if there is none
+	selX, selY int           // anchor of the selection in the internal buffer
+
 	Hidden bool // if true the view will not be drawn
 
 	// BgColor and FgColor allow to configure the background and foreground
@@ -127,9 +137,19 @@ type View struct {
 	BgColor, FgColor Attribute
 
 	// SelBgColor and SelFgColor are used to configure the background and
-	// foreground colors of the selected line, when it is highlighted.
+	// foreground colors of the selected text.
 	SelBgColor, SelFgColor Attribute
 
+	// CursorLineBgColor and CursorLineFgColor are used to configure the
+	// colors of the line under the cursor, when it is highlighted.
+	// Sel{Bg,Fg}Color are used if they are not set.
+	CursorLineBgColor, CursorLineFgColor Attribute
+
+	// SearchBgColor and SearchFgColor are used to configure the colors of
+	// the matches of the last search, when they are highlighted.
+	// Sel{Bg,Fg}Color are used if they are not set.
+	SearchBgColor, SearchFgColor Attribute
+
 	// If Editable is true, keystrokes will be added to the view's internal
 	// buffer at the cursor position.
 	Editable bool
@@ -137,18 +157,50 @@ type View struct {
 	// Overwrite enables or disables the overwrite mode of the view.
 	Overwrite bool
 
-	// If Highlight is true, Sel{Bg,Fg}Colors will be used
+	// If Highlight is true, CursorLine{Bg,Fg}Colors will be used
 	// for the line under the cursor position.
 	Highlight bool
 
+	// If HighlightSearch is true, Search{Bg,Fg}Colors will be used for the
+	// matches of the last search.
+	HighlightSearch bool
+
 	// If Frame is true, a border will be drawn around the view.
 	Frame bool
 
+	// Border defines the style of each side of the frame.
+	Border Border
+
 	// If Wrap is true, the content that is written to this View is
 	// automatically wrapped when it is longer than its width. If true the
 	// view's x-origin will be ignored.
 	Wrap bool
 
+	// If Wrap and WordWrap are true, lines are wrapped after a space, a
+	// punctuation mark or a wide character when possible, instead of after
+	// the last character fitting in the view.
+	WordWrap bool
+
+	// If Wrap and WrapIndent are true, the continuation lines of a wrapped
+	// line are indented like the line, up to half of the view's width.
+	WrapIndent bool
+
+	// If Wrap is true and WrapMarker is not 0, WrapMarker is drawn in a
+	// column on the left of the continuation lines of a wrapped line.
+	WrapMarker rune
+
+	// If SignColumn is true, the signs of the lines are displayed in a
+	// column on the left of the view, see Sign.
+	SignColumn bool
+
+	// LineNumbers defines how the numbers of the lines of the internal
+	// buffer are displayed on the left of the view, see LineNumbers.
+	LineNumbers LineNumbers
+
+	// NumberBgColor and NumberFgColor allow to configure the colors of the
+	// line numbers. The colors of the view are used if they are not set.
+	NumberBgColor, NumberFgColor Attribute
+
 	// If Autoscroll is true, the View will automatically scroll down when the
 	// text overflows. If true the view's y-origin will be ignored.
 	Autoscroll bool
@@ -161,14 +213,45 @@ type View struct {
 	// Title will be placed on the bottom-right corner
 	Footer string
 
+	// If Frame is true, TitleBar and FooterBar allow to configure richer
+	// titles and footers, see Bar. Title is drawn before the left segments
+	// of TitleBar and Footer after the right segments of FooterBar.
+	TitleBar, FooterBar Bar
+
 	// If Mask is true, the View will display the mask instead of the real
 	// content
 	Mask rune
+
+	// TabWidth is the distance between two tab stops, 8 if it is not set.
+	// Tabs are kept in the internal buffer and displayed as spaces up to
+	// the next tab stop.
+	TabWidth int
+
+	// If ExpandTab is true, EditTab inserts spaces up to the next tab stop
+	// instead of a tab.
+	ExpandTab bool
 }
 
 type viewLine struct {
 	linesX, linesY int // coordinates relative to v.lines
-	line           []rune
+	line           []cell
+	indent         int // number of blank columns before line
+}
+
+// cell is a rune of the view's internal buffer with its colors.
+type cell struct {
+	chr              rune
+	fgColor, bgColor Attribute
+	styled           bool // if false, the view's colors are used
+}
+
+// cellsString returns the runes of cs as a string.
+func cellsString(cs []cell) string {
+	rs := make([]rune, len(cs))
+	for i, c := range cs {
+		rs[i] = c.chr
+	}
+	return string(rs)
 }
 
 // newView returns a new View object.
@@ -209,21 +292,22 @@ func (v *View) BufferSize() int {
 	return len(v.lines)
 }
 
-// setRune writes a rune at the given point, relative to the view. It
-// checks if the position is valid and applies the view's colors, taking
-// into account if the cell must be highlighted.
-func (v *View) setRune(x, y int, ch rune) error {
-	maxX, maxY := v.Size()
+// setRune writes a cell at the given point, relative to the view. It
+// checks if the position is valid and applies the colors of the cell, or
+// the view's ones if it is not styled, taking into account if the cell
+// must be highlighted.
+func (v *View) setRune(x, y int, c cell, match bool) error {
+	maxX, maxY := v.textSize()
 	if x < 0 || x >= maxX || y < 0 || y >= maxY {
 		return errors.New("invalid point")
 	}
 
 	var (
-		ry, rcy int
-		err     error
+		rx, ry, rcy int
+		err         error
 	)
-	if v.Highlight {
-		_, ry, err = v.realPosition(x, y)
+	if v.Highlight || v.selMode != SelectNone {
+		rx, ry, err = v.realPosition(x, y)
 		if err != nil {
 			return err
 		}
@@ -234,34 +318,69 @@ func (v *View) setRune(x, y int, ch rune) error {
 	}
 
 	var fgColor, bgColor Attribute
-	if v.Highlight && ry == rcy {
-		fgColor = v.SelFgColor
-		bgColor = v.SelBgColor
-	} else {
-		fgColor = v.FgColor
-		bgColor = v.BgColor
-	}
-
+	switch {
+	case v.selMode != SelectNone && v.selected(rx, ry):
+		fgColor, bgColor = v.SelFgColor, v.SelBgColor
+	case match:
+		fgColor, bgColor = v.selColors(v.SearchFgColor, v.SearchBgColor)
+	case v.Highlight && ry == rcy:
+		fgColor, bgColor = v.selColors(v.CursorLineFgColor, v.CursorLineBgColor)
+	case c.styled:
+		fgColor, bgColor = c.fgColor, c.bgColor
+	default:
+		fgColor, bgColor = v.FgColor, v.BgColor
+	}
+
+	ch := c.chr
 	if v.Mask != 0 {
 		ch = v.Mask
 	}
-	termbox.SetCell(v.x0+x+1, v.y0+y+1, ch,
-		termbox.Attribute(fgColor), termbox.Attribute(bgColor))
+	v.screen.SetCell(v.x0+v.gutterWidth()+x+1, v.y0+y+1, ch, fgColor, bgColor)
 	return nil
 }
 
+// selColors returns fg and bg, replaced by the colors of the selection if
+// they are not set.
+func (v *View) selColors(fg, bg Attribute) (Attribute, Attribute) {
+	if fg == ColorDefault {
+		fg = v.SelFgColor
+	}
+	if bg == ColorDefault {
+		bg = v.SelBgColor
+	}
+	return fg, bg
+}
+
 // SetCursor sets the cursor position of the view at the given point,
-// relative to the view. It checks if the position is valid.
+// relative to the view. It checks if the position is valid. A point on
+// the second column of a wide character moves the cursor to its first
+// column.
 func (v *View) SetCursor(x, y int) error {
-	maxX, maxY := v.Size()
+	maxX, maxY := v.textSize()
 	if x < 0 || x >= maxX || y < 0 || y >= maxY {
 		return errors.New("invalid point")
 	}
 	v.cx = x
 	v.cy = y
+	v.snapCursor()
 	return nil
 }
 
+// snapCursor moves the cursor to the first column of the character under
+// it, if it is visible.
+func (v *View) snapCursor() {
+	vy := v.oy + v.cy
+	if vy < 0 || vy >= len(v.viewLines) {
+		return
+	}
+	vline := v.viewLines[vy]
+	col := v.ox + v.cx
+	tw := v.tabWidth()
+	if start := vline.column(vline.index(col, tw), tw); start != col && start >= v.ox {
+		v.cx = start - v.ox
+	}
+}
+
 // Cursor returns the cursor position of the view.
 func (v *View) Cursor() (x, y int) {
 	return v.cx, v.cy
@@ -290,10 +409,33 @@ func (v *View) Origin() (x, y int) {
 // View implements the io.Writer interface, it can be passed as parameter
 // of functions like fmt.Fprintf, fmt.Fprintln, io.Copy, etc. Clear must
 // be called to clear the view's buffer.
+//
+// ANSI SGR escape sequences set the colors of the runes that follow them,
+// the other escape sequences are dropped. Buffer returns the text without
+// the escape sequences.
 func (v *View) Write(p []byte) (n int, err error) {
+	v.write(p, cell{})
+	return len(p), nil
+}
+
+// WriteStyled works like Write, but the written runes are displayed with
+// the given colors instead of the view's ones.
+func (v *View) WriteStyled(fg, bg Attribute, p []byte) (n int, err error) {
+	v.write(p, cell{fgColor: fg, bgColor: bg, styled: true})
+	return len(p), nil
+}
+
+// write appends the runes of p into the view's internal buffer, using the
+// colors of style.
+func (v *View) write(p []byte, style cell) {
 	v.tainted = true
 
 	for _, ch := range bytes.Runes(p) {
+		if v.ei.parseOne(ch) {
+			continue
+		}
+		c := v.ei.apply(v, style)
+		c.chr = ch
 		switch ch {
 		case '\n':
 			v.lines = append(v.lines, nil)
@@ -302,18 +444,68 @@ func (v *View) Write(p []byte) (n int, err error) {
 			if nl > 0 {
 				v.lines[nl-1] = nil
 			} else {
-				v.lines = make([][]rune, 1)
+				v.lines = make([][]cell, 1)
 			}
 		default:
 			nl := len(v.lines)
 			if nl > 0 {
-				v.lines[nl-1] = append(v.lines[nl-1], ch)
+				v.lines[nl-1] = append(v.lines[nl-1], c)
 			} else {
-				v.lines = append(v.lines, []rune{ch})
+				v.lines = append(v.lines, []cell{c})
 			}
 		}
 	}
-	return len(p), nil
+}
+
+// SetStyle sets the colors of the runes of the internal buffer from the
+// point (x0, y0) included to the point (x1, y1) excluded. The points are
+// buffer coordinates, the range can span several lines, and an error is
+// returned if it is not in the buffer. The colors follow the runes when
+// the buffer is edited.
+func (v *View) SetStyle(x0, y0, x1, y1 int, fg, bg Attribute) error {
+	return v.setStyle(x0, y0, x1, y1, cell{fgColor: fg, bgColor: bg, styled: true})
+}
+
+// ClearStyle makes the runes of the given range use the view's colors
+// again. See SetStyle.
+func (v *View) ClearStyle(x0, y0, x1, y1 int) error {
+	return v.setStyle(x0, y0, x1, y1, cell{})
+}
+
+// setStyle copies the colors of style to the cells of the given range.
+func (v *View) setStyle(x0, y0, x1, y1 int, style cell) error {
+	if !validRange(v.lines, x0, y0, x1, y1) {
+		return errors.New("invalid range")
+	}
+	v.tainted = true
+
+	for y := y0; y < len(v.lines) && y <= y1; y++ {
+		from, to := 0, len(v.lines[y])
+		if y == y0 {
+			from = x0
+		}
+		if y == y1 {
+			to = x1
+		}
+		for x := from; x < to; x++ {
+			v.lines[y][x].fgColor = style.fgColor
+			v.lines[y][x].bgColor = style.bgColor
+			v.lines[y][x].styled = style.styled
+		}
+	}
+	return nil
+}
+
+// Style returns the colors used to display the rune at the point (x, y)
+// of the internal buffer.
+func (v *View) Style(x, y int) (fg, bg Attribute, err error) {
+	if x < 0 || y < 0 || y >= len(v.lines) || x >= len(v.lines[y]) {
+		return 0, 0, errors.New("invalid point")
+	}
+	if c := v.lines[y][x]; c.styled {
+		return c.fgColor, c.bgColor, nil
+	}
+	return v.FgColor, v.BgColor, nil
 }
 
 // Read reads data into p. It returns the number of bytes read into p.
@@ -340,7 +532,7 @@ func (v *View) Rewind() {
 
 // draw re-draws the view's contents.
 func (v *View) draw() error {
-	maxX, maxY := v.Size()
+	maxX, maxY := v.textSize()
 	if v.Wrap {
 		maxX--
 	}
@@ -352,38 +544,18 @@ func (v *View) draw() error {
 		v.ox = 0
 	}
 	if v.tainted {
-		v.viewLines = nil
-		for i, line := range v.lines {
-			if v.Wrap {
-				if len(line) <= maxX {
-					vline := viewLine{linesX: 0, linesY: i, line: line}
-					v.viewLines = append(v.viewLines, vline)
-					continue
-				} else {
-					vline := viewLine{linesX: 0, linesY: i, line: line[:maxX]}
-					v.viewLines = append(v.viewLines, vline)
-				}
-				// Append remaining lines
-				for n := maxX; n < len(line); n += maxX {
-					if len(line[n:]) <= maxX {
-						vline := viewLine{linesX: n, linesY: i, line: line[n:]}
-						v.viewLines = append(v.viewLines, vline)
-					} else {
-						vline := viewLine{linesX: n, linesY: i, line: line[n : n+maxX]}
-						v.viewLines = append(v.viewLines, vline)
-					}
-				}
-			} else {
-				vline := viewLine{linesX: 0, linesY: i, line: line}
-				v.viewLines = append(v.viewLines, vline)
-			}
-		}
-		v.tainted = false
+		v.updateViewLines()
 	}
 
+	tw := v.tabWidth()
 	if v.Autoscroll && len(v.viewLines) > maxY {
 		v.oy = len(v.viewLines) - maxY
 	}
+	_, cy, _ := v.realPosition(v.cx, v.cy)
+	var (
+		matches []bool // matched cells of the line matchY
+		matchY  = -1
+	)
 	y := 0
 	for i, vline := range v.viewLines {
 		if i < v.oy {
@@ -392,26 +564,70 @@ func (v *View) draw() error {
 		if y >= maxY {
 			break
 		}
-		x := 0
-		for j, ch := range vline.line {
-			if j < v.ox {
-				continue
-			}
+		v.drawGutter(y, vline, cy)
+		if v.HighlightSearch && vline.linesY != matchY {
+			matches, matchY = v.searchMatches(vline.linesY), vline.linesY
+		}
+		col := 0
+		for j, c := range vline.line {
+			match := matches != nil && matches[vline.linesX+j]
+			x := vline.indent + col - v.ox
 			if x >= maxX {
 				break
 			}
-			if err := v.setRune(x, y, ch); err != nil {
-				return err
+			w := charWidth(c.chr, col, tw)
+			switch {
+			case w == 0:
+			case c.chr == '\t':
+				blank := c
+				blank.chr = ' '
+				for tx := x; tx < x+w && tx < maxX; tx++ {
+					if tx < 0 {
+						continue
+					}
+					if err := v.setRune(tx, y, blank, match); err != nil {
+						return err
+					}
+				}
+			case x >= 0 && x+w <= maxX:
+				// a wide character cut by the edges of the view is not drawn
+				if err := v.setRune(x, y, c, match); err != nil {
+					return err
+				}
 			}
-			x++
+			col += w
 		}
 		y++
 	}
 	return nil
 }
 
+// updateViewLines splits the lines of the internal buffer in the lines
+// displayed by the view. If Wrap is true, a line is split at the last
+// character fitting in the width of the view, or at the last space or
+// punctuation mark if WordWrap is true.
+func (v *View) updateViewLines() {
+	// the last column is kept for the cursor at the end of a full line
+	maxX, _ := v.textSize()
+	if maxX--; maxX < 1 {
+		maxX = 1
+	}
+
+	tw := v.tabWidth()
+	v.viewLines = nil
+	for i, line := range v.lines {
+		if !v.Wrap {
+			v.viewLines = append(v.viewLines, viewLine{linesX: 0, linesY: i, line: line})
+			continue
+		}
+		v.viewLines = append(v.viewLines, v.wrapLine(i, line, maxX, tw)...)
+	}
+	v.tainted = false
+}
+
 // realPosition returns the position in the internal buffer corresponding to the
-// point (x, y) of the view.
+// point (x, y) of the view. x is a column, a point on the second column of
+// a wide character gives the position of this character.
 func (v *View) realPosition(vx, vy int) (x, y int, err error) {
 	vx = v.ox + vx
 	vy = v.oy + vy
@@ -426,7 +642,7 @@ func (v *View) realPosition(vx, vy int) (x, y int, err error) {
 
 	if vy < len(v.viewLines) {
 		vline := v.viewLines[vy]
-		x = vline.linesX + vx
+		x = vline.linesX + vline.index(vx, v.tabWidth())
 		y = vline.linesY
 	} else {
 		vline := v.viewLines[len(v.viewLines)-1]
@@ -442,6 +658,9 @@ func (v *View) Clear() {
 	v.tainted = true
 
 	v.lines = nil
+	v.signs = nil
+	v.selMode = SelectNone
+	v.ei = escapeInterpreter{}
 	v.clearRunes()
 }
 
@@ -450,128 +669,67 @@ func (v *View) clearRunes() {
 	maxX, maxY := v.Size()
 	for x := 0; x < maxX; x++ {
 		for y := 0; y < maxY; y++ {
-			termbox.SetCell(v.x0+x+1, v.y0+y+1, ' ',
-				termbox.Attribute(v.FgColor), termbox.Attribute(v.BgColor))
+			v.screen.SetCell(v.x0+x+1, v.y0+y+1, ' ', v.FgColor, v.BgColor)
 		}
 	}
 }
 
-func (v *View) absWriteRune(x, y int, ch rune) error {
-	v.tainted = true
-
-	if x < 0 || y < 0 {
-		return errors.New("invalid point")
-	}
-
-	if y >= len(v.lines) {
-		s := make([][]rune, y-len(v.lines)+1)
-		v.lines = append(v.lines, s...)
-	}
-
-	olen := len(v.lines[y])
-	if x >= len(v.lines[y]) {
-		s := make([]rune, x-len(v.lines[y])+1)
-		v.lines[y] = append(v.lines[y], s...)
-	}
-	if !v.Overwrite && x < olen {
-		v.lines[y] = append(v.lines[y], '\x00')
-		copy(v.lines[y][x+1:], v.lines[y][x:])
-	}
-	v.lines[y][x] = ch
-	return nil
-}
-
-// writeRune writes a rune into the view's internal buffer, at the
-// position corresponding to the point (x, y). The length of the internal
-// buffer is increased if the point is out of bounds. Overwrite mode is
-// governed by the value of View.overwrite.
-func (v *View) writeRune(x, y int, ch rune) error {
-	rx, ry, err := v.realPosition(x, y)
-	if err != nil {
-		return err
-	}
-	return v.absWriteRune(rx, ry, ch)
-}
-
-func (v *View) absDeleteRune(x, y int) error {
-	v.tainted = true
-
-	if x < 0 || y < 0 || y >= len(v.lines) || x >= len(v.lines[y]) {
-		return errors.New("invalid point")
-	}
-	v.lines[y] = append(v.lines[y][:x], v.lines[y][x+1:]...)
-	return nil
-}
-
-// deleteRune removes a rune from the view's internal buffer, at the
-// position corresponding to the point (x, y).
-func (v *View) deleteRune(x, y int) error {
-
-	rx, ry, err := v.realPosition(x, y)
-	if err != nil {
-		return err
-	}
-	return v.absDeleteRune(rx, ry)
-}
-
-func (v *View) absMergeLines(y int) error {
+// replaceText replaces the text of the internal buffer from (x0, y0) to
+// (x1, y1), excluded, with text, whose elements are lines separated by line
+// breaks. The range must be in the buffer, except in an empty buffer.
+func (v *View) replaceText(x0, y0, x1, y1 int, text [][]cell) {
 	v.tainted = true
 
-	if y < 0 || y >= len(v.lines) {
-		return errors.New("invalid point")
+	var prefix, suffix []cell
+	n := 0 // number of replaced lines
+	if y0 < len(v.lines) {
+		prefix, suffix = v.lines[y0][:x0], v.lines[y1][x1:]
+		n = y1 - y0 + 1
+	}
+	last := len(text) - 1
+	lines := make([][]cell, len(text))
+	for i, t := range text {
+		var line []cell
+		if i == 0 {
+			line = append(line, prefix...)
+		}
+		line = append(line, t...)
+		if i == last {
+			line = append(line, suffix...)
+		}
+		lines[i] = line
 	}
+	v.replaceSigns(x0, y0, x1, y1, lines)
 
-	if y < len(v.lines)-1 { // otherwise we don't need to merge anything
-		v.lines[y] = append(v.lines[y], v.lines[y+1]...)
-		v.lines = append(v.lines[:y+1], v.lines[y+2:]...)
-		return nil
-	}
-	return errors.New("last line")
+	buf := make([][]cell, 0, len(v.lines)-n+len(lines))
+	buf = append(buf, v.lines[:y0]...)
+	buf = append(buf, lines...)
+	v.lines = append(buf, v.lines[y0+n:]...)
 }
 
-// mergeLines merges the lines "y" and "y+1" if possible.
-func (v *View) mergeLines(y int) error {
-	_, ry, err := v.realPosition(0, y)
-	if err != nil {
-		return err
+// textRange returns the text of the internal buffer from (x0, y0) to
+// (x1, y1), excluded, as lines separated by line breaks.
+func (v *View) textRange(x0, y0, x1, y1 int) [][]cell {
+	if y0 >= len(v.lines) {
+		return [][]cell{nil}
 	}
-	return v.absMergeLines(ry)
-}
-
-func (v *View) absBreakLine(x, y int) error {
-	v.tainted = true
-
-	if y < 0 || y >= len(v.lines) {
-		return errors.New("invalid point")
+	if y0 == y1 {
+		return [][]cell{append([]cell(nil), v.lines[y0][x0:x1]...)}
 	}
-
-	var left, right []rune
-	if x < len(v.lines[y]) { // break line
-		left = make([]rune, len(v.lines[y][:x]))
-		copy(left, v.lines[y][:x])
-		right = make([]rune, len(v.lines[y][x:]))
-		copy(right, v.lines[y][x:])
-	} else { // new empty line
-		left = v.lines[y]
+	text := [][]cell{append([]cell(nil), v.lines[y0][x0:]...)}
+	for y := y0 + 1; y < y1; y++ {
+		text = append(text, append([]cell(nil), v.lines[y]...))
 	}
-
-	lines := make([][]rune, len(v.lines)+1)
-	lines[y] = left
-	lines[y+1] = right
-	copy(lines, v.lines[:y])
-	copy(lines[y+2:], v.lines[y+1:])
-	v.lines = lines
-	return nil
+	return append(text, append([]cell(nil), v.lines[y1][:x1]...))
 }
 
-// breakLine breaks a line of the internal buffer at the position corresponding
-// to the point (x, y).
-func (v *View) breakLine(x, y int) error {
-	rx, ry, err := v.realPosition(x, y)
-	if err != nil {
-		return err
+// textEnd returns the position following text, inserted at (x, y).
+func textEnd(x, y int, text [][]cell) (int, int) {
+	last := len(text) - 1
+	if last == 0 {
+		return x + len(text[0]), y
 	}
-	return v.absBreakLine(rx, ry)
+	return len(text[last]), y + last
 }
 
 // Buffer returns a string with the contents of the view's internal
@@ -579,7 +737,7 @@ func (v *View) breakLine(x, y int) error {
 func (v *View) Buffer() string {
 	str := ""
 	for _, l := range v.lines {
-		str += string(l) + "\n"
+		str += cellsString(l) + "\n"
 	}
 	return strings.Replace(str, "\x00", " ", -1)
 }
@@ -589,7 +747,7 @@ func (v *View) Buffer() string {
 func (v *View) ViewBuffer() string {
 	str := ""
 	for _, l := range v.viewLines {
-		str += string(l.line) + "\n"
+		str += cellsString(l.line) + "\n"
 	}
 	return strings.Replace(str, "\x00", " ", -1)
 }
@@ -605,7 +763,7 @@ func (v *View) Line(y int) (string, error) {
 	if y < 0 || y >= len(v.lines) {
 		return "", errors.New("invalid point")
 	}
-	return string(v.lines[y]), nil
+	return cellsString(v.lines[y]), nil
 }
 
 // Word returns a string with the word of the view's internal buffer
@@ -619,26 +777,22 @@ func (v *View) Word(x, y int) (string, error) {
 	if x < 0 || y < 0 || y >= len(v.lines) || x >= len(v.lines[y]) {
 		return "", errors.New("invalid point")
 	}
-	l := string(v.lines[y])
-	nl := strings.LastIndexFunc(l[:x], indexFunc)
-	if nl == -1 {
-		nl = 0
-	} else {
-		nl = nl + 1
+	l := []rune(cellsString(v.lines[y]))
+	nl := x
+	for nl > 0 && !indexFunc(l[nl-1]) {
+		nl--
 	}
-	nr := strings.IndexFunc(l[x:], indexFunc)
-	if nr == -1 {
-		nr = len(l)
-	} else {
-		nr = nr + x
+	nr := x
+	for nr < len(l) && !indexFunc(l[nr]) {
+		nr++
 	}
 	return string(l[nl:nr]), nil
 }
 
-// indexFunc allows to split lines by words taking into account spaces
-// and 0.
+// indexFunc allows to split lines by words taking into account spaces,
+// tabs and 0.
 func indexFunc(r rune) bool {
-	return r == ' ' || r == 0
+	return r == ' ' || r == '\t' || r == 0
 }
 
 func (v *View) SetEditable(b bool) {
@@ -647,16 +801,3 @@ func (v *View) SetEditable(b bool) {
 func (v *View) IsEditable() bool {
 	return v.Editable
 }
-
-// permutLines permuts y1 and y2
-func (v *View) permutLines(y1, y2 int) error {
-	if y1 < 0 || y2 < 0 || y1 >= len(v.lines) || y2 >= len(v.lines) {
-		return errors.New("invalid line")
-	}
-
-	s := v.lines[y1]
-	v.lines[y1] = v.lines[y2]
-	v.lines[y2] = s
-
-	return nil
-}
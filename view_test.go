@@ -0,0 +1,125 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"fmt"
+	"testing"
+)
+
+// styleHarness returns a harness with a view of 6x3 cells, initialized by
+// init.
+func styleHarness(t *testing.T, init func(v *View)) (*Harness, *View) {
+	var v *View
+	h, err := NewHarness(8, 5, testLayout(viewSpec{"a", 0, 0, 7, 4, func(nv *View) {
+		v = nv
+		init(v)
+	}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h, v
+}
+
+// checkColors checks the colors of the cells of the view at (0, 0) of h
+// against want, which holds 'v' for the colors of the view and 's' for
+// fg and bg, row by row.
+func checkColors(t *testing.T, name string, h *Harness, v *View, want []string, fg, bg Attribute) {
+	for y, row := range want {
+		for x, c := range row {
+			wfg, wbg := v.FgColor, v.BgColor
+			if c == 's' {
+				wfg, wbg = fg, bg
+			}
+			_, gfg, gbg := h.Screen.Cell(x+1, y+1)
+			if gfg != wfg || gbg != wbg {
+				t.Errorf("%s: cell (%d, %d) fg=%v bg=%v, want fg=%v bg=%v", name, x, y, gfg, gbg, wfg, wbg)
+			}
+			if x >= len(v.lines[y]) {
+				continue
+			}
+			if sfg, sbg, err := v.Style(x, y); err != nil || sfg != wfg || sbg != wbg {
+				t.Errorf("%s: Style(%d, %d) = %v, %v, %v, want %v, %v", name, x, y, sfg, sbg, err, wfg, wbg)
+			}
+		}
+	}
+}
+
+func TestWriteStyled(t *testing.T) {
+	h, v := styleHarness(t, func(v *View) {
+		fmt.Fprint(v, "ab")
+		v.WriteStyled(ColorRed, ColorBlue, []byte("cd\nef"))
+		fmt.Fprint(v, "g\nh")
+	})
+	defer h.Close()
+
+	if got, want := v.Buffer(), "abcd\nefg\nh\n"; got != want {
+		t.Errorf("buffer %q, want %q", got, want)
+	}
+	checkColors(t, "WriteStyled", h, v, []string{"vvssvv", "ssvvvv", "vvvvvv"}, ColorRed, ColorBlue)
+}
+
+func TestSetStyle(t *testing.T) {
+	h, v := styleHarness(t, func(v *View) {
+		fmt.Fprint(v, "abc\ndefg\nhi")
+	})
+	defer h.Close()
+
+	// the range spans three lines, the line breaks are not styled
+	if err := v.SetStyle(2, 0, 1, 2, ColorGreen, ColorMagenta); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	checkColors(t, "SetStyle", h, v, []string{"vvsvvv", "ssssvv", "svvvvv"}, ColorGreen, ColorMagenta)
+
+	// the colors follow the runes
+	if err := v.ReplaceRange(0, 1, 0, 1, "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	checkColors(t, "edit", h, v, []string{"vvsvvv", "vssssv", "svvvvv"}, ColorGreen, ColorMagenta)
+
+	if err := v.ClearStyle(0, 1, 5, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	checkColors(t, "ClearStyle", h, v, []string{"vvsvvv", "vvvvvv", "svvvvv"}, ColorGreen, ColorMagenta)
+}
+
+func TestSetStyleInvalid(t *testing.T) {
+	tests := []struct {
+		name           string
+		x0, y0, x1, y1 int
+	}{
+		{"negative column", -1, 0, 1, 0},
+		{"negative line", 0, -1, 1, 0},
+		{"reversed lines", 0, 1, 0, 0},
+		{"reversed columns", 2, 0, 1, 0},
+		{"column after the line", 0, 0, 4, 0},
+		{"start after the line", 5, 1, 0, 2},
+		{"line after the buffer", 0, 0, 0, 3},
+	}
+	h, v := styleHarness(t, func(v *View) {
+		fmt.Fprint(v, "abc\ndefg\nhi")
+	})
+	defer h.Close()
+
+	for _, tt := range tests {
+		if err := v.SetStyle(tt.x0, tt.y0, tt.x1, tt.y1, ColorRed, ColorBlue); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+	if err := h.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	// no rune is styled
+	checkColors(t, "invalid", h, v, []string{"vvvvvv", "vvvvvv", "vvvvvv"}, ColorRed, ColorBlue)
+}
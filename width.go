@@ -0,0 +1,96 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import "github.com/mattn/go-runewidth"
+
+// The columns of a view are cells of the screen, while the x coordinates
+// of the internal buffer are indexes of runes. East Asian wide characters
+// use two columns, and combining characters use none: they belong to the
+// character before them. termbox cannot display a combining character on
+// top of another one, so they are kept in the buffer but not drawn. Tabs
+// use the columns up to the next tab stop.
+
+// defaultTabWidth is the distance between two tab stops when
+// View.TabWidth is not set.
+const defaultTabWidth = 8
+
+// runeWidth returns the number of columns used to display ch. Control
+// characters and the padding of the buffer use one column.
+func runeWidth(ch rune) int {
+	if ch < ' ' {
+		return 1
+	}
+	return runewidth.RuneWidth(ch)
+}
+
+// charWidth returns the number of columns used to display ch at the column
+// col, with tab stops every tabWidth columns.
+func charWidth(ch rune, col, tabWidth int) int {
+	if ch == '\t' {
+		return tabWidth - col%tabWidth
+	}
+	return runeWidth(ch)
+}
+
+// lineWidth returns the number of columns used to display cs.
+func lineWidth(cs []cell, tabWidth int) int {
+	w := 0
+	for _, c := range cs {
+		w += charWidth(c.chr, w, tabWidth)
+	}
+	return w
+}
+
+// columnIndex returns the index of the cell of cs displayed at the column
+// col, or the index of the character covering it. Columns beyond the end
+// of cs give indexes beyond its end, one per column.
+func columnIndex(cs []cell, col, tabWidth int) int {
+	w := 0
+	for i, c := range cs {
+		cw := charWidth(c.chr, w, tabWidth)
+		if cw == 0 {
+			continue
+		}
+		if w+cw > col {
+			return i
+		}
+		w += cw
+	}
+	return len(cs) + col - w
+}
+
+// indexColumn returns the first column of the cell of cs at the index i.
+// It is the reverse of columnIndex.
+func indexColumn(cs []cell, i, tabWidth int) int {
+	if i > len(cs) {
+		return lineWidth(cs, tabWidth) + i - len(cs)
+	}
+	return lineWidth(cs[:i], tabWidth)
+}
+
+// clusterEnd returns the index following the character at the index i of
+// cs and its combining characters.
+func clusterEnd(cs []cell, i int) int {
+	for i++; i < len(cs) && runeWidth(cs[i].chr) == 0; i++ {
+	}
+	return i
+}
+
+// clusterStart returns the index of the character before the index i of
+// cs, skipping its combining characters.
+func clusterStart(cs []cell, i int) int {
+	for i--; i > 0 && runeWidth(cs[i].chr) == 0; i-- {
+	}
+	return i
+}
+
+// tabWidth returns the distance between two tab stops of the view.
+func (v *View) tabWidth() int {
+	if v.TabWidth > 0 {
+		return v.TabWidth
+	}
+	return defaultTabWidth
+}
@@ -0,0 +1,137 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import "strings"
+
+// wrapPunctuation holds the punctuation marks after which a line can be
+// wrapped when WordWrap is true.
+const wrapPunctuation = ",.;:!?-/)]}"
+
+// The columns of a line of the view start after its indentation, which is
+// only set on the continuation lines of a wrapped line. The tab stops of a
+// line of the view are relative to the start of its text.
+
+// width returns the number of columns used to display the line.
+func (vl viewLine) width(tabWidth int) int {
+	return vl.indent + lineWidth(vl.line, tabWidth)
+}
+
+// index returns the index of the cell of the line displayed at the column
+// col. Columns of the indentation give the first cell.
+func (vl viewLine) index(col, tabWidth int) int {
+	if col < vl.indent {
+		col = vl.indent
+	}
+	return columnIndex(vl.line, col-vl.indent, tabWidth)
+}
+
+// column returns the first column of the cell of the line at the index i.
+func (vl viewLine) column(i, tabWidth int) int {
+	return vl.indent + indexColumn(vl.line, i, tabWidth)
+}
+
+// wrapLine splits the line y of the internal buffer in lines of the view
+// of at most maxX columns.
+func (v *View) wrapLine(y int, line []cell, maxX, tabWidth int) []viewLine {
+	indent := 0
+	if v.WrapIndent {
+		indent = leadingWidth(line, tabWidth)
+		if indent > maxX/2 {
+			indent = maxX / 2
+		}
+	}
+
+	// the line is not wrapped inside its indentation
+	lead := 0
+	for lead < len(line) && (line[lead].chr == ' ' || line[lead].chr == '\t') {
+		lead++
+	}
+
+	var vlines []viewLine
+	start, brk, w, vindent := 0, 0, 0, 0
+	for j := 0; j < len(line); j++ {
+		ch := line[j].chr
+		cw := charWidth(ch, w, tabWidth)
+		if vindent+w+cw > maxX && j > start {
+			end := j
+			if v.WordWrap && brk > start {
+				end = brk
+			}
+			vlines = append(vlines, viewLine{linesX: start, linesY: y, line: line[start:end], indent: vindent})
+			// the characters following the break are laid out again
+			start, brk, w, vindent = end, end, 0, indent
+			j = start - 1
+			continue
+		}
+		w += cw
+		if isWrapPoint(ch) && j >= lead {
+			brk = clusterEnd(line, j)
+		}
+	}
+	return append(vlines, viewLine{linesX: start, linesY: y, line: line[start:], indent: vindent})
+}
+
+// isWrapPoint reports whether a line can be wrapped after ch: a space, a
+// punctuation mark or a wide character.
+func isWrapPoint(ch rune) bool {
+	return ch == ' ' || ch == '\t' || strings.ContainsRune(wrapPunctuation, ch) || runeWidth(ch) > 1
+}
+
+// leadingWidth returns the number of columns of the spaces and tabs at
+// the start of cs.
+func leadingWidth(cs []cell, tabWidth int) int {
+	w := 0
+	for _, c := range cs {
+		if c.chr != ' ' && c.chr != '\t' {
+			break
+		}
+		w += charWidth(c.chr, w, tabWidth)
+	}
+	return w
+}
+
+// viewPosition returns the line of the view and the column displaying the
+// position (x, y) of the internal buffer. A position at the boundary of
+// two lines of the view is displayed at the end of the first one.
+func (v *View) viewPosition(x, y int) (vy, col int) {
+	tw := v.tabWidth()
+	for i := len(v.viewLines) - 1; i >= 0; i-- {
+		vline := v.viewLines[i]
+		if vline.linesY > y {
+			continue
+		}
+		if vline.linesY < y {
+			return i + y - vline.linesY, x
+		}
+		if vline.linesX < x || vline.linesX == 0 {
+			return i, vline.column(x-vline.linesX, tw)
+		}
+	}
+	return y, x
+}
+
+// placeCursor moves the cursor to the position (x, y) of the internal
+// buffer and scrolls the view vertically to keep it visible. It is used
+// when Wrap is true, since an edit can move the following words to
+// another line of the view. The view is scrolled back when the lines of
+// the view after the origin do not fill it anymore.
+func (v *View) placeCursor(x, y int) {
+	v.updateViewLines()
+	vy, col := v.viewPosition(x, y)
+	_, maxY := v.Size()
+	if n := len(v.viewLines) - maxY; v.oy > n {
+		v.oy = n
+		if v.oy < 0 {
+			v.oy = 0
+		}
+	}
+	if vy < v.oy {
+		v.oy = vy
+	} else if vy >= v.oy+maxY {
+		v.oy = vy - maxY + 1
+	}
+	v.ox, v.cx, v.cy = 0, col, vy-v.oy
+}
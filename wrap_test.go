@@ -0,0 +1,186 @@
+// Copyright 2014 The gocui Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gocui
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestWordWrap(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "spaces",
+			text: "hello big world",
+			want: lines(
+				"┌────────┐",
+				"│hello   │",
+				"│big     │",
+				"│world   │",
+				"└────────┘",
+			),
+		},
+		{
+			name: "punctuation",
+			text: "one-two,three",
+			want: lines(
+				"┌────────┐",
+				"│one-    │",
+				"│two,    │",
+				"│three   │",
+				"└────────┘",
+			),
+		},
+		{
+			name: "long word",
+			text: "abcdefghijkl",
+			want: lines(
+				"┌────────┐",
+				"│abcdefg │",
+				"│hijkl   │",
+				"│        │",
+				"└────────┘",
+			),
+		},
+	}
+	for _, tt := range tests {
+		h, err := NewHarness(10, 5, testLayout(viewSpec{"a", 0, 0, 9, 4, func(v *View) {
+			v.Wrap = true
+			v.WordWrap = true
+			fmt.Fprint(v, tt.text)
+		}}))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := h.Screen.String(); got != tt.want {
+			t.Errorf("%s: got\n%swant\n%s", tt.name, got, tt.want)
+		}
+		h.Close()
+	}
+}
+
+func TestWordWrapIndentation(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		indent bool
+		want   string
+	}{
+		{
+			name: "indented word",
+			text: "    abcdefgh",
+			want: lines(
+				"┌─────────┐",
+				"│    abcd │",
+				"│efgh     │",
+				"│         │",
+				"└─────────┘",
+			),
+		},
+		{
+			name:   "indented word with wrap indent",
+			text:   "    abcdefgh",
+			indent: true,
+			want: lines(
+				"┌─────────┐",
+				"│    abcd │",
+				"│    efgh │",
+				"│         │",
+				"└─────────┘",
+			),
+		},
+		{
+			name: "indented words",
+			text: "    ab cdefgh",
+			want: lines(
+				"┌─────────┐",
+				"│    ab   │",
+				"│cdefgh   │",
+				"│         │",
+				"└─────────┘",
+			),
+		},
+		{
+			name: "blank line",
+			text: "            ",
+			want: lines(
+				"┌─────────┐",
+				"│         │",
+				"│         │",
+				"│         │",
+				"└─────────┘",
+			),
+		},
+	}
+	for _, tt := range tests {
+		h, err := NewHarness(11, 5, testLayout(viewSpec{"a", 0, 0, 10, 4, func(v *View) {
+			v.Wrap = true
+			v.WordWrap = true
+			v.WrapIndent = tt.indent
+			fmt.Fprint(v, tt.text)
+		}}))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := h.Screen.String(); got != tt.want {
+			t.Errorf("%s: got\n%swant\n%s", tt.name, got, tt.want)
+		}
+		h.Close()
+	}
+}
+
+func TestWrapScrollBack(t *testing.T) {
+	var v *View
+	h, err := NewHarness(8, 5, testLayout(viewSpec{"a", 0, 0, 7, 4, func(nv *View) {
+		v = nv
+		v.Editable = true
+		v.Wrap = true
+		fmt.Fprint(v, "a\nb\nc\nddddddddd")
+	}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	v.AbsMoveCursor(9, 3, false)
+	if err := h.Draw(); err != nil {
+		t.Fatal(err)
+	}
+	want := lines(
+		"┌──────┐",
+		"│c     │",
+		"│ddddd │",
+		"│dddd  │",
+		"└──────┘",
+	)
+	if got := h.Screen.String(); got != want {
+		t.Errorf("at the end: got\n%swant\n%s", got, want)
+	}
+
+	// the content shrinks by one line of the view
+	for i := 0; i < 5; i++ {
+		v.EditDelete(true)
+		if err := h.Draw(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want = lines(
+		"┌──────┐",
+		"│b     │",
+		"│c     │",
+		"│dddd  │",
+		"└──────┘",
+	)
+	if got := h.Screen.String(); got != want {
+		t.Errorf("after deleting: got\n%swant\n%s", got, want)
+	}
+	if _, oy := v.Origin(); oy != 1 {
+		t.Errorf("origin at line %d, want 1", oy)
+	}
+}